@@ -0,0 +1,285 @@
+// chat builds Minecraft's JSON text components, the rich text format used by tellraw, title, bossbar and friends.
+// Components are built up by chaining, e.g.
+//
+//	chat.Text("Server restarting in ").Color(chat.Gold).Append(chat.Text("5 minutes").Bold(true))
+//
+// and the helpers like Tellraw turn them into complete commands to pass to SendCommand.
+package chat
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/joshproehl/minecontrol/formatting"
+)
+
+// Color is a named text colour, or a hex colour like "#ff8800".
+type Color string
+
+const (
+	Black       Color = "black"
+	DarkBlue    Color = "dark_blue"
+	DarkGreen   Color = "dark_green"
+	DarkAqua    Color = "dark_aqua"
+	DarkRed     Color = "dark_red"
+	DarkPurple  Color = "dark_purple"
+	Gold        Color = "gold"
+	Gray        Color = "gray"
+	DarkGray    Color = "dark_gray"
+	Blue        Color = "blue"
+	Green       Color = "green"
+	Aqua        Color = "aqua"
+	Red         Color = "red"
+	LightPurple Color = "light_purple"
+	Yellow      Color = "yellow"
+	White       Color = "white"
+)
+
+// ClickAction is what happens when a player clicks on a component.
+type ClickAction string
+
+const (
+	OpenURL         ClickAction = "open_url"
+	RunCommand      ClickAction = "run_command"
+	SuggestCommand  ClickAction = "suggest_command"
+	ChangePage      ClickAction = "change_page"
+	CopyToClipboard ClickAction = "copy_to_clipboard"
+)
+
+// Component is a JSON text component. The zero value is an empty text component. Build them with Text, Translate,
+// Score or Selector and the chaining methods, which all modify and return the component they're called on.
+type Component struct {
+	text      *string
+	translate string
+	with      []*Component
+	score     *score
+	selector  string
+
+	color         Color
+	bold          *bool
+	italic        *bool
+	underlined    *bool
+	strikethrough *bool
+	obfuscated    *bool
+	insertion     string
+
+	clickEvent *clickEvent
+	hoverEvent *hoverEvent
+	extra      []*Component
+}
+
+type score struct {
+	Name      string `json:"name"`
+	Objective string `json:"objective"`
+}
+
+type clickEvent struct {
+	Action ClickAction `json:"action"`
+	Value  string      `json:"value"`
+}
+
+type hoverEvent struct {
+	Action   string     `json:"action"`
+	Contents *Component `json:"contents"`
+}
+
+// Text makes a component showing literal text.
+func Text(s string) *Component {
+	return &Component{text: &s}
+}
+
+// Translate makes a component showing a translated string from the game's language files, with the with components
+// substituted in, e.g. Translate("multiplayer.player.joined", Text("Notch")).
+func Translate(key string, with ...*Component) *Component {
+	return &Component{translate: key, with: with}
+}
+
+// Score makes a component showing a scoreboard value. name may be a player name or a selector like "@p".
+func Score(name, objective string) *Component {
+	return &Component{score: &score{Name: name, Objective: objective}}
+}
+
+// Selector makes a component showing the names of the entities matching an entity selector, like "@a[distance=..10]".
+func Selector(selector string) *Component {
+	return &Component{selector: selector}
+}
+
+// Color sets the text colour.
+func (c *Component) Color(color Color) *Component {
+	c.color = color
+	return c
+}
+
+// Bold sets or clears bold. Components inherit their parent's formatting unless it's set on them.
+func (c *Component) Bold(on bool) *Component {
+	c.bold = &on
+	return c
+}
+
+// Italic sets or clears italics.
+func (c *Component) Italic(on bool) *Component {
+	c.italic = &on
+	return c
+}
+
+// Underlined sets or clears underlining.
+func (c *Component) Underlined(on bool) *Component {
+	c.underlined = &on
+	return c
+}
+
+// Strikethrough sets or clears strikethrough.
+func (c *Component) Strikethrough(on bool) *Component {
+	c.strikethrough = &on
+	return c
+}
+
+// Obfuscated sets or clears the scrambled text effect.
+func (c *Component) Obfuscated(on bool) *Component {
+	c.obfuscated = &on
+	return c
+}
+
+// Insertion sets text that's put into the player's chat box when they shift-click the component.
+func (c *Component) Insertion(s string) *Component {
+	c.insertion = s
+	return c
+}
+
+// OnClick sets what happens when the component is clicked, e.g. OnClick(chat.OpenURL, "https://minecraft.net").
+func (c *Component) OnClick(action ClickAction, value string) *Component {
+	c.clickEvent = &clickEvent{Action: action, Value: value}
+	return c
+}
+
+// OnHover sets a tooltip shown when the component is hovered over.
+func (c *Component) OnHover(tooltip *Component) *Component {
+	c.hoverEvent = &hoverEvent{Action: "show_text", Contents: tooltip}
+	return c
+}
+
+// Append adds children after the component's own content. They inherit its formatting.
+func (c *Component) Append(children ...*Component) *Component {
+	c.extra = append(c.extra, children...)
+	return c
+}
+
+// MarshalJSON encodes the component in the game's format. Formatting that hasn't been set is left out, so it's
+// inherited, and a component with no content becomes an empty text component.
+func (c *Component) MarshalJSON() ([]byte, error) {
+	out := struct {
+		Text          *string      `json:"text,omitempty"`
+		Translate     string       `json:"translate,omitempty"`
+		With          []*Component `json:"with,omitempty"`
+		Score         *score       `json:"score,omitempty"`
+		Selector      string       `json:"selector,omitempty"`
+		Color         Color        `json:"color,omitempty"`
+		Bold          *bool        `json:"bold,omitempty"`
+		Italic        *bool        `json:"italic,omitempty"`
+		Underlined    *bool        `json:"underlined,omitempty"`
+		Strikethrough *bool        `json:"strikethrough,omitempty"`
+		Obfuscated    *bool        `json:"obfuscated,omitempty"`
+		Insertion     string       `json:"insertion,omitempty"`
+		ClickEvent    *clickEvent  `json:"clickEvent,omitempty"`
+		HoverEvent    *hoverEvent  `json:"hoverEvent,omitempty"`
+		Extra         []*Component `json:"extra,omitempty"`
+	}{
+		Text:          c.text,
+		Translate:     c.translate,
+		With:          c.with,
+		Score:         c.score,
+		Selector:      c.selector,
+		Color:         c.color,
+		Bold:          c.bold,
+		Italic:        c.italic,
+		Underlined:    c.underlined,
+		Strikethrough: c.strikethrough,
+		Obfuscated:    c.obfuscated,
+		Insertion:     c.insertion,
+		ClickEvent:    c.clickEvent,
+		HoverEvent:    c.hoverEvent,
+		Extra:         c.extra,
+	}
+
+	if out.Text == nil && out.Translate == "" && out.Score == nil && out.Selector == "" {
+		empty := ""
+		out.Text = &empty
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(out); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// String returns the component's JSON.
+func (c *Component) String() string {
+	b, err := c.MarshalJSON()
+	if err != nil {
+		return fmt.Sprintf(`{"text":%q}`, err.Error())
+	}
+	return string(b)
+}
+
+// FromLegacy converts text with § formatting codes into a component, with one child per differently formatted run.
+func FromLegacy(s string) *Component {
+	root := Text("")
+
+	for _, seg := range formatting.Parse(s) {
+		child := Text(seg.Text)
+		// Siblings don't inherit from each other, only from the unformatted root, so only what's set needs writing out.
+		child.color = Color(seg.Style.Color)
+		setIf(&child.bold, seg.Style.Bold)
+		setIf(&child.italic, seg.Style.Italic)
+		setIf(&child.underlined, seg.Style.Underlined)
+		setIf(&child.strikethrough, seg.Style.Strikethrough)
+		setIf(&child.obfuscated, seg.Style.Obfuscated)
+		root.Append(child)
+	}
+
+	return root
+}
+
+func setIf(flag **bool, on bool) {
+	if on {
+		*flag = &on
+	}
+}
+
+// Tellraw returns a command sending c to the chat of the players matched by target, e.g. "@a".
+func Tellraw(target string, c *Component) string {
+	return fmt.Sprintf("tellraw %s %s", target, c)
+}
+
+// TitleSlot is where on screen Title shows its component.
+type TitleSlot string
+
+const (
+	TitleMain TitleSlot = "title"
+	Subtitle  TitleSlot = "subtitle"
+	ActionBar TitleSlot = "actionbar"
+)
+
+// Title returns a command showing c as a title, subtitle or above the hotbar for the players matched by target.
+// A subtitle is only shown along with the next title.
+func Title(target string, slot TitleSlot, c *Component) string {
+	return fmt.Sprintf("title %s %s %s", target, slot, c)
+}
+
+// TitleTimes returns a command setting how long titles fade in, stay and fade out for, in ticks.
+func TitleTimes(target string, fadeIn, stay, fadeOut int) string {
+	return fmt.Sprintf("title %s times %d %d %d", target, fadeIn, stay, fadeOut)
+}
+
+// BossbarAdd returns a command creating a boss bar with the given namespaced ID, like "minecontrol:restart".
+func BossbarAdd(id string, name *Component) string {
+	return fmt.Sprintf("bossbar add %s %s", id, name)
+}
+
+// BossbarSetName returns a command changing the text on an existing boss bar.
+func BossbarSetName(id string, name *Component) string {
+	return fmt.Sprintf("bossbar set %s name %s", id, name)
+}
@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"github.com/joshproehl/minecontrol/mcrcon"
+	"github.com/joshproehl/minecontrol/mcrcon/cmdtree"
+	"github.com/joshproehl/minecontrol/ping"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+	"net"
+	"strconv"
+	"time"
+)
+
+var treeCache *cmdtree.Cache
+
+// loadCommandTree finds out what commands the server at address:port has, for completing and checking commands before
+// they're sent. The tree is cached for that server and its version, which is asked for with a Server List Ping on the
+// game port, until it's older than cmdtree.maxAge. With refresh, or --refreshCommands, it's discovered again regardless.
+// It returns nil if the commands can't be discovered, which the tree's methods treat as knowing nothing.
+func loadCommandTree(c mcrcon.Commander, address string, port int, refresh bool) *cmdtree.Tree {
+	if treeCache == nil {
+		dir := viper.GetString("cmdtree.cache")
+		if dir == "" {
+			dir = cmdtree.DefaultCacheDir()
+		}
+		treeCache = cmdtree.NewCache(dir)
+		treeCache.MaxAge = viper.GetDuration("cmdtree.maxAge")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	version := ""
+	if status, err := ping.Ping(ctx, address, viper.GetInt("game.port")); err == nil {
+		version = fmt.Sprintf("%s (%d)", status.Version.Name, status.Version.Protocol)
+	}
+
+	server := net.JoinHostPort(address, strconv.Itoa(port))
+	load := treeCache.Load
+	if refresh || viper.GetBool("cmdtree.refresh") {
+		load = treeCache.Refresh
+	}
+
+	tree, err := load(ctx, c, server, version)
+	if err != nil {
+		jww.WARN.Println("Could not discover the server's commands, so they won't be checked before sending:", err)
+		return nil
+	}
+	return tree
+}
@@ -0,0 +1,206 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/joshproehl/minecontrol/mcrcon"
+	"github.com/spf13/viper"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// vanillaCommands is what's offered when completing the first word of a line if the server's own list couldn't be
+// discovered. Commands a server doesn't have are harmless, it'll just say they're unknown.
+var vanillaCommands = []string{
+	"advancement", "attribute", "ban", "ban-ip", "banlist", "bossbar", "clear", "clone", "damage", "data", "datapack",
+	"debug", "defaultgamemode", "deop", "difficulty", "effect", "enchant", "execute", "experience", "fill", "fillbiome",
+	"forceload", "function", "gamemode", "gamerule", "give", "help", "item", "kick", "kill", "list", "locate", "loot",
+	"me", "msg", "op", "pardon", "pardon-ip", "particle", "place", "playsound", "recipe", "reload", "ride", "save-all",
+	"save-off", "save-on", "say", "schedule", "scoreboard", "seed", "setblock", "setidletimeout", "setworldspawn",
+	"spawnpoint", "spectate", "spreadplayers", "stop", "stopsound", "summon", "tag", "team", "teleport", "tell",
+	"tellraw", "tick", "time", "title", "tp", "trigger", "w", "weather", "whitelist", "worldborder", "xp",
+}
+
+var vanillaGamerules = []string{
+	"announceAdvancements", "blockExplosionDropDecay", "commandBlockOutput", "commandModificationBlockLimit",
+	"disableElytraMovementCheck", "disableRaids", "doDaylightCycle", "doEntityDrops", "doFireTick",
+	"doImmediateRespawn", "doInsomnia", "doLimitedCrafting", "doMobLoot", "doMobSpawning", "doPatrolSpawning",
+	"doTileDrops", "doTraderSpawning", "doVinesSpread", "doWardenSpawning", "doWeatherCycle", "drowningDamage",
+	"enderPearlsVanishOnDeath", "fallDamage", "fireDamage", "forgiveDeadPlayers", "freezeDamage", "globalSoundEvents",
+	"keepInventory", "lavaSourceConversion", "logAdminCommands", "maxCommandChainLength", "maxCommandForkCount",
+	"maxEntityCramming", "mobExplosionDropDecay", "mobGriefing", "naturalRegeneration",
+	"playersNetherPortalCreativeDelay", "playersNetherPortalDefaultDelay", "playersSleepingPercentage",
+	"projectilesCanBreakBlocks", "randomTickSpeed", "reducedDebugInfo", "sendCommandFeedback", "showDeathMessages",
+	"snowAccumulationHeight", "spawnChunkRadius", "spawnRadius", "spectatorsGenerateChunks", "tntExplosionDropDecay",
+	"universalAnger", "waterSourceConversion",
+}
+
+// commonItems is a starting set of item and block IDs. The full lists depend on the server version, so for those point
+// repl.registries at the registries.json the server's data generator writes, see loadRegistries.
+var commonItems = []string{
+	"air", "andesite", "anvil", "apple", "arrow", "barrier", "beacon", "bedrock", "bow", "bread", "bucket", "cake",
+	"chest", "clock", "coal", "coal_block", "cobblestone", "command_block", "compass", "cooked_beef", "crafting_table",
+	"diamond", "diamond_axe", "diamond_block", "diamond_boots", "diamond_chestplate", "diamond_helmet",
+	"diamond_leggings", "diamond_pickaxe", "diamond_shovel", "diamond_sword", "dirt", "elytra", "emerald",
+	"enchanted_golden_apple", "ender_pearl", "experience_bottle", "firework_rocket", "flint_and_steel", "furnace",
+	"glass", "glowstone", "gold_block", "gold_ingot", "golden_apple", "golden_carrot", "grass_block", "gravel",
+	"iron_axe", "iron_block", "iron_ingot", "iron_pickaxe", "iron_sword", "ladder", "lava_bucket", "lever", "map",
+	"netherite_ingot", "netherite_pickaxe", "netherite_sword", "oak_log", "oak_planks", "obsidian", "redstone",
+	"redstone_block", "redstone_torch", "sand", "shield", "shulker_box", "spawner", "stick", "stone", "structure_block",
+	"tnt", "torch", "totem_of_undying", "trident", "water_bucket", "white_wool",
+}
+
+// argCompleter offers the candidates for one argument of a command.
+type argCompleter func(r *repl) []string
+
+func keywords(words ...string) argCompleter {
+	return func(*repl) []string { return words }
+}
+
+func players(r *repl) []string    { return r.playerNames() }
+func gamerules(*repl) []string    { return vanillaGamerules }
+func items(r *repl) []string      { return r.itemIDs }
+func blocks(r *repl) []string     { return r.blockIDs }
+func noCompletion(*repl) []string { return nil }
+
+var gameModeNames = keywords("survival", "creative", "adventure", "spectator")
+
+// commandArgs says what goes in each argument of the commands we can complete more than the name of.
+var commandArgs = map[string][]argCompleter{
+	"ban":             {players},
+	"clear":           {players, items},
+	"defaultgamemode": {gameModeNames},
+	"deop":            {players},
+	"difficulty":      {keywords("peaceful", "easy", "normal", "hard")},
+	"fill":            {noCompletion, noCompletion, noCompletion, noCompletion, noCompletion, noCompletion, blocks},
+	"gamemode":        {gameModeNames, players},
+	"gamerule":        {gamerules, keywords("true", "false")},
+	"give":            {players, items},
+	"kick":            {players},
+	"kill":            {players},
+	"msg":             {players},
+	"op":              {players},
+	"pardon":          {players},
+	"setblock":        {noCompletion, noCompletion, noCompletion, blocks},
+	"tell":            {players},
+	"time":            {keywords("add", "query", "set")},
+	"tp":              {players, players},
+	"teleport":        {players, players},
+	"w":               {players},
+	"weather":         {keywords("clear", "rain", "thunder")},
+	"whitelist":       {keywords("add", "list", "off", "on", "reload", "remove"), players},
+}
+
+var metaCommands = []string{":format", ":help", ":quit", ":reconnect", ":refresh", ":server", ":validate"}
+
+// complete is the REPL's liner.WordCompleter. It works out which argument of which command the cursor is in, and offers
+// whatever fits there.
+func (r *repl) complete(line string, pos int) (head string, completions []string, tail string) {
+	start := strings.LastIndexAny(line[:pos], " \t") + 1
+	head, word, tail := line[:start], line[start:pos], line[pos:]
+	before := strings.Fields(head)
+
+	var candidates []string
+	switch {
+	case len(before) == 0:
+		commands := r.tree.Commands()
+		if len(commands) == 0 {
+			commands = vanillaCommands
+		}
+		candidates = append(append(candidates, metaCommands...), commands...)
+	case before[0] == ":server" && len(before) == 1:
+		for name := range viper.GetStringMap("profiles") {
+			candidates = append(candidates, name)
+		}
+	case before[0] == ":format" && len(before) == 1:
+		candidates = []string{"json", "raw", "text"}
+	case before[0] == ":validate" && len(before) == 1:
+		candidates = []string{"off", "on"}
+	default:
+		// The server's help knows its subcommands, and we know which arguments are players, items and so on.
+		candidates = r.tree.Complete(before)
+		cmd := strings.ToLower(strings.TrimPrefix(before[0], "/"))
+		if args := commandArgs[cmd]; len(before)-1 < len(args) {
+			candidates = append(candidates, args[len(before)-1](r)...)
+		}
+	}
+
+	// Item and block IDs may be typed with or without their namespace.
+	prefix := ""
+	if strings.HasPrefix(word, "minecraft:") {
+		prefix, word = "minecraft:", strings.TrimPrefix(word, "minecraft:")
+	}
+
+	seen := make(map[string]bool)
+	for _, c := range candidates {
+		if !seen[c] && strings.HasPrefix(strings.ToLower(c), strings.ToLower(word)) {
+			completions = append(completions, prefix+c+" ")
+			seen[c] = true
+		}
+	}
+	sort.Strings(completions)
+
+	return head, completions, tail
+}
+
+// playerNames returns who's online, asking the server at most every 30 seconds.
+func (r *repl) playerNames() []string {
+	if time.Since(r.playersAt) < 30*time.Second {
+		return r.players
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	pl, err := mcrcon.ListPlayers(ctx, r.client)
+	if err != nil {
+		return r.players
+	}
+
+	r.players = r.players[:0]
+	for _, p := range pl.Players {
+		r.players = append(r.players, p.Name)
+	}
+	r.playersAt = time.Now()
+
+	return r.players
+}
+
+// loadRegistries reads item and block IDs from a registries.json made by the server's data generator, e.g. with
+// "java -DbundlerMainClass=net.minecraft.data.Main -jar server.jar --reports". Anything missing falls back to
+// commonItems.
+func (r *repl) loadRegistries(path string) {
+	r.itemIDs, r.blockIDs = commonItems, commonItems
+	if path == "" {
+		return
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	var registries map[string]struct {
+		Entries map[string]json.RawMessage `json:"entries"`
+	}
+	if err := json.NewDecoder(f).Decode(&registries); err != nil {
+		return
+	}
+
+	ids := func(registry string) []string {
+		var out []string
+		for id := range registries[registry].Entries {
+			out = append(out, strings.TrimPrefix(id, "minecraft:"))
+		}
+		return out
+	}
+	if found := ids("minecraft:item"); len(found) > 0 {
+		r.itemIDs = found
+	}
+	if found := ids("minecraft:block"); len(found) > 0 {
+		r.blockIDs = found
+	}
+}
@@ -4,6 +4,7 @@ package commands
 import (
 	"fmt"
 	"github.com/howeyc/gopass"
+	"github.com/joshproehl/minecontrol/mcrcon/cmdtree"
 	"github.com/spf13/cobra"
 	jww "github.com/spf13/jwalterweatherman"
 	"github.com/spf13/viper"
@@ -31,7 +32,7 @@ var mcCmd = &cobra.Command{
 			os.Exit(0)
 		}
 
-		if viper.GetString("rcon.password") == "" { // Should detect if we have a password via config or flag, and only execute this if NOT
+		if needsRCON(cmd) && viper.GetString("rcon.password") == "" { // Should detect if we have a password via config or flag, and only execute this if NOT
 			fmt.Printf("Enter RCON password: ")
 			passwd := string(gopass.GetPasswd())
 			viper.Set("rcon.password", passwd)
@@ -41,8 +42,8 @@ var mcCmd = &cobra.Command{
 
 // Flag values
 var fvAddress, fvPassword string
-var fvPort int
-var fvVerbose, fvVersion bool
+var fvPort, fvQueryPort, fvGamePort int
+var fvVerbose, fvVersion, fvRefreshCommands bool
 
 // GetGoing is what sets up the app, and then runs Execute() on whichever command was called.
 func GetGoing() {
@@ -74,13 +75,20 @@ func getConfigFile() {
 	viper.BindPFlag("rcon.address", mcCmd.PersistentFlags().Lookup("address"))
 	viper.BindPFlag("rcon.port", mcCmd.PersistentFlags().Lookup("port"))
 	viper.BindPFlag("rcon.password", mcCmd.PersistentFlags().Lookup("password"))
+	viper.BindPFlag("query.port", mcCmd.PersistentFlags().Lookup("queryPort"))
+	viper.BindPFlag("game.port", mcCmd.PersistentFlags().Lookup("gamePort"))
+	viper.BindPFlag("cmdtree.refresh", mcCmd.PersistentFlags().Lookup("refreshCommands"))
+	viper.SetDefault("cmdtree.maxAge", cmdtree.DefaultMaxAge)
 }
 
 func addFlags() {
 	mcCmd.PersistentFlags().StringVarP(&fvAddress, "address", "a", "127.0.0.1", "The IP address or domain name of the server to connect to")
 	mcCmd.PersistentFlags().IntVarP(&fvPort, "port", "p", 25566, "The port number that minecraft is running on at the provided address")
 	mcCmd.PersistentFlags().StringVarP(&fvPassword, "password", "P", "", "The RCON Password needed to connect to the server")
+	mcCmd.PersistentFlags().IntVar(&fvQueryPort, "queryPort", 25565, "The port the server answers Query protocol requests on")
+	mcCmd.PersistentFlags().IntVar(&fvGamePort, "gamePort", 25565, "The port players connect to the server on")
 	mcCmd.PersistentFlags().BoolVar(&fvVersion, "version", false, "Print the version number and exit")
+	mcCmd.PersistentFlags().BoolVar(&fvRefreshCommands, "refreshCommands", false, "Discover the server's commands again, rather than using the cached list")
 	mcCmd.PersistentFlags().BoolVar(&fvVerbose, "verbose", false, "Set verbose mode. (Logs even more to the logfile)")
 }
 
@@ -88,4 +96,21 @@ func addCommands() {
 	mcCmd.AddCommand(runCmd)
 	mcCmd.AddCommand(replCmd)
 	mcCmd.AddCommand(serverCmd)
+	mcCmd.AddCommand(proxyCmd)
+	mcCmd.AddCommand(queryCmd)
+	mcCmd.AddCommand(pingCmd)
+	mcCmd.AddCommand(sayCmd)
+	mcCmd.AddCommand(tokenCmd)
+	mcCmd.AddCommand(hashPasswordCmd)
+}
+
+// needsRCON reports whether cmd talks to the server over RCON, and so needs the RCON password.
+func needsRCON(cmd *cobra.Command) bool {
+	for c := cmd; c != nil; c = c.Parent() {
+		switch c {
+		case queryCmd, pingCmd, tokenCmd, hashPasswordCmd:
+			return false
+		}
+	}
+	return true
 }
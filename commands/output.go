@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/joshproehl/minecontrol/formatting"
+	"golang.org/x/term"
+	"os"
+	"strings"
+)
+
+// stdoutFormat is how server output should be rendered for printing: in colour on a terminal, and as plain text when
+// going to a file or pipe, where escape codes would just be noise.
+func stdoutFormat() formatting.Format {
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		return formatting.ANSI
+	}
+	return formatting.Plain
+}
+
+// renderResponse renders a command response for printing to stdout.
+func renderResponse(s string) string {
+	return formatting.Render(s, stdoutFormat())
+}
+
+// commandResult is what the json output format prints for each command.
+type commandResult struct {
+	Command  string `json:"command"`
+	Response string `json:"response"`
+	Error    string `json:"error,omitempty"`
+}
+
+// validOutputFormat reports whether f is one of the formats printResponse understands.
+func validOutputFormat(f string) bool {
+	switch f {
+	case "text", "json", "raw":
+		return true
+	}
+	return false
+}
+
+// printResponse prints the response to command in one of the output formats: text is rendered for stdout, json is a
+// commandResult per line, and raw is exactly what the server sent. cmdErr is any failure the server reported.
+func printResponse(format, command, response string, cmdErr error) {
+	switch format {
+	case "json":
+		result := commandResult{Command: command, Response: formatting.Strip(response)}
+		if cmdErr != nil {
+			result.Error = cmdErr.Error()
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetEscapeHTML(false)
+		enc.Encode(result)
+	case "raw":
+		fmt.Print(response)
+		if !strings.HasSuffix(response, "\n") {
+			fmt.Println()
+		}
+	default:
+		fmt.Println(renderResponse(response))
+	}
+}
@@ -0,0 +1,62 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/joshproehl/minecontrol/ping"
+	"github.com/spf13/cobra"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+	"os"
+	"strings"
+	"time"
+)
+
+var pingCmd = &cobra.Command{
+	Use:   "ping",
+	Short: "Check the server is up using the Server List Ping protocol",
+	Long: `Ask the server for its status the same way the multiplayer menu does: version, player counts, MOTD and latency.
+This works on any server players can connect to, even with RCON and Query turned off.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runPing(viper.GetString("rcon.address"), viper.GetInt("game.port"), fvPingJSON)
+	},
+}
+
+var fvPingJSON bool
+
+func init() {
+	pingCmd.Flags().BoolVar(&fvPingJSON, "json", false, "Print the results as JSON")
+}
+
+// runPing pings the server and prints its status.
+func runPing(address string, port int, asJSON bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	status, err := ping.Ping(ctx, address, port)
+	if err != nil {
+		jww.FATAL.Println(fmt.Sprintf("Could not ping %s:%d. (Error was: %s)", address, port, err))
+		os.Exit(1)
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(status)
+		return
+	}
+
+	fmt.Println("Version: ", status.Version.Name, fmt.Sprintf("(protocol %d)", status.Version.Protocol))
+	fmt.Println("MOTD:    ", renderResponse(status.MOTD))
+	fmt.Printf("Players:  %d/%d", status.Players.Online, status.Players.Max)
+	if len(status.Players.Sample) > 0 {
+		names := make([]string, len(status.Players.Sample))
+		for i, p := range status.Players.Sample {
+			names[i] = renderResponse(p.Name)
+		}
+		fmt.Print(" ", strings.Join(names, ", "))
+	}
+	fmt.Println()
+	fmt.Printf("Latency:  %dms\n", status.LatencyMS)
+}
@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"github.com/joshproehl/minecontrol/mcrcon"
+	"github.com/spf13/cobra"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+	"io"
+	"os"
+	"time"
+)
+
+var proxyCmd = &cobra.Command{
+	Use:   "proxy",
+	Short: "Share one RCON connection between many RCON clients",
+	Long: `Listen as an RCON server and pass every command through to the Minecraft server over a single connection,
+one at a time, so any number of scripts, dashboards and bots can use RCON at once.
+Each client logs in with its own password, set under "proxy.clients" in the config file as name: password pairs.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runProxy(viper.GetString("proxy.listen"), viper.GetStringMapString("proxy.clients"), viper.GetDuration("proxy.timeout"))
+	},
+}
+
+func init() {
+	proxyCmd.Flags().String("listen", ":25576", "Address to accept RCON clients on")
+	proxyCmd.Flags().Duration("timeout", 30*time.Second, "How long to wait for the Minecraft server to answer a command")
+	viper.BindPFlag("proxy.listen", proxyCmd.Flags().Lookup("listen"))
+	viper.BindPFlag("proxy.timeout", proxyCmd.Flags().Lookup("timeout"))
+}
+
+// runProxy connects upstream and then serves RCON on listen until something goes wrong.
+func runProxy(listen string, clients map[string]string, timeout time.Duration) {
+	if len(clients) == 0 {
+		jww.FATAL.Println("No proxy clients configured. Add name: password pairs under proxy.clients in minecontrol.json.")
+		os.Exit(1)
+	}
+
+	upstream, err := mcrcon.NewClient(viper.GetString("rcon.address"), viper.GetInt("rcon.port"), viper.GetString("rcon.password"))
+	if err != nil {
+		jww.FATAL.Println(err)
+		os.Exit(1)
+	}
+	defer upstream.Close()
+
+	upstream.SetReconnectPolicy(mcrcon.DefaultReconnectPolicy())
+	upstream.OnStateChange(func(s mcrcon.ConnState) {
+		jww.INFO.Println("Upstream RCON connection state:", s)
+	})
+
+	srv := &mcrcon.Server{
+		Addr:         listen,
+		Authenticate: proxyAuthenticator(clients),
+		Handler:      &proxyHandler{upstream: upstream, timeout: timeout},
+	}
+
+	fmt.Println("Starting RCON proxy on", listen)
+	if err := srv.ListenAndServe(); err != nil {
+		jww.FATAL.Println(err)
+		os.Exit(1)
+	}
+}
+
+// proxyAuthenticator checks a login against every client's password, returning the name of the one that matched.
+func proxyAuthenticator(clients map[string]string) func(string) (string, bool) {
+	return func(password string) (string, bool) {
+		identity, ok := "", false
+		// Check them all, so how long it takes doesn't give away which (if any) matched.
+		for name, p := range clients {
+			if subtle.ConstantTimeCompare([]byte(password), []byte(p)) == 1 {
+				identity, ok = name, true
+			}
+		}
+		return identity, ok
+	}
+}
+
+// proxyHandler forwards each downstream command over the single upstream connection. MCRCONClient runs one command at
+// a time using request IDs of its own, and mcrcon.Server answers every downstream client with the request ID that client
+// sent, which between them keeps each client's responses its own.
+type proxyHandler struct {
+	upstream *mcrcon.MCRCONClient
+	timeout  time.Duration
+}
+
+func (h *proxyHandler) ServeRCON(w io.Writer, r *mcrcon.Request) {
+	jww.INFO.Printf("Proxying command from %s (%s): %s", r.Identity, r.RemoteAddr, r.Command)
+
+	// Deliberately not tied to the downstream request: a client hanging up part way through shouldn't take the shared
+	// upstream connection down with it.
+	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	defer cancel()
+
+	response, err := h.upstream.SendCommandContext(ctx, r.Command)
+	if err != nil {
+		jww.ERROR.Printf("Command from %s failed: %s", r.Identity, err)
+		fmt.Fprintf(w, "minecontrol proxy: %s", err)
+		return
+	}
+
+	io.WriteString(w, response)
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/joshproehl/minecontrol/query"
+	"github.com/spf13/cobra"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+	"os"
+	"strings"
+	"time"
+)
+
+var queryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Show the server's details using the Query protocol",
+	Long: `Ask the server for its MOTD, version, map, plugins and players using the UDP Query protocol.
+The server must have enable-query=true in server.properties. No RCON password is needed.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		runQuery(viper.GetString("rcon.address"), viper.GetInt("query.port"), fvQueryJSON)
+	},
+}
+
+var fvQueryJSON bool
+
+func init() {
+	queryCmd.Flags().BoolVar(&fvQueryJSON, "json", false, "Print the results as JSON")
+}
+
+// runQuery does a full stat request against the server and prints what comes back.
+func runQuery(address string, port int, asJSON bool) {
+	client, err := query.NewClient(address, port)
+	if err != nil {
+		jww.FATAL.Println(err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stat, err := client.FullStat(ctx)
+	if err != nil {
+		jww.FATAL.Println(fmt.Sprintf("Could not query %s:%d. (Error was: %s)", address, port, err))
+		os.Exit(1)
+	}
+
+	if asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(stat)
+		return
+	}
+
+	fmt.Println("MOTD:      ", renderResponse(stat.MOTD))
+	fmt.Println("Version:   ", stat.Version)
+	fmt.Println("Game type: ", stat.GameType)
+	fmt.Println("Map:       ", stat.Map)
+	if stat.ServerMod != "" {
+		fmt.Println("Server mod:", stat.ServerMod)
+	}
+	if len(stat.Plugins) > 0 {
+		fmt.Println("Plugins:   ", strings.Join(stat.Plugins, ", "))
+	}
+	fmt.Printf("Players:    %d/%d %s\n", stat.NumPlayers, stat.MaxPlayers, strings.Join(stat.Players, ", "))
+}
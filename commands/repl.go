@@ -1,11 +1,22 @@
 package commands
 
 import (
-	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"github.com/joshproehl/minecontrol/mcrcon"
+	"github.com/joshproehl/minecontrol/mcrcon/cmdtree"
+	rcmd "github.com/joshproehl/minecontrol/mcrcon/commands"
+	"github.com/peterh/liner"
 	"github.com/spf13/cobra"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
 var replCmd = &cobra.Command{
@@ -14,46 +25,304 @@ var replCmd = &cobra.Command{
 	Long: `Read
 	Evaluate
 	Print
-	Loop`,
+	Loop
+
+` + replHelp,
 	Run: func(cmd *cobra.Command, args []string) {
-		//runREPL()
+		runREPL(viper.GetString("rcon.address"), viper.GetInt("rcon.port"), viper.GetString("rcon.password"))
 	},
 }
 
+// replHelp is shown by :help, as well as in the command's own help.
+const replHelp = `Commands, player names, game rules and item IDs complete with tab, and Ctrl-R searches back through the history,
+which is kept between sessions. End a line with \ to carry on the command on the next one, and start it with ! to send
+it without checking it against the server's help.
+
+Lines starting with : are handled by minecontrol itself:
+  :reconnect         Drop the connection and connect again
+  :server <profile>  Switch to the server in the profiles.<profile> section of the config file
+  :format <format>   Print responses as text, json or raw
+  :validate on|off   Whether to check commands against the server's help before sending them
+  :refresh           Find out the server's commands again, e.g. after adding a plugin
+  :quit              Leave, as does Ctrl-D
+
+Ctrl-C clears the line being typed, or abandons a command that's taking too long.`
+
+var fvReplHistory, fvReplRegistries string
+
+func init() {
+	replCmd.Flags().StringVar(&fvReplHistory, "history", "", "The file to keep command history in (default ~/.minecontrol_history)")
+	replCmd.Flags().StringVar(&fvReplRegistries, "registries", "", "A registries.json from the server's data generator, to complete every item and block ID")
+	viper.BindPFlag("repl.history", replCmd.Flags().Lookup("history"))
+	viper.BindPFlag("repl.registries", replCmd.Flags().Lookup("registries"))
+}
+
+// repl is the state of an interactive session.
+type repl struct {
+	line     *liner.State
+	client   *mcrcon.MCRCONClient
+	tree     *cmdtree.Tree
+	format   string
+	validate bool
+
+	// Where we're connected to. profile is empty for the server given by the rcon settings.
+	profile  string
+	address  string
+	port     int
+	password string
+
+	// Completion data, see complete.go.
+	players   []string
+	playersAt time.Time
+	itemIDs   []string
+	blockIDs  []string
+}
+
 // runREPL takes an address and password, then sest up a connection to the RCON server and presents the user with a
 // read-evaluate-print-loop command prompt for the connected RCON server.
 func runREPL(address string, port int, password string) {
-	client, err := mcrcon.NewClient(address, port, password)
+	r := &repl{format: "text", validate: true, address: address, port: port, password: password}
 
-	if err != nil {
+	if err := r.connect(); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
 	}
 
-	fmt.Println("Type \"exit\" to quit")
+	r.line = liner.NewLiner()
+	defer r.line.Close()
+	r.line.SetCtrlCAborts(true)
+	r.line.SetWordCompleter(r.complete)
+	r.line.SetTabCompletionStyle(liner.TabPrints)
+	r.loadRegistries(viper.GetString("repl.registries"))
 
-	inputreader := bufio.NewReader(os.Stdin)
+	historyFile := r.historyFile()
+	if f, err := os.Open(historyFile); err == nil {
+		r.line.ReadHistory(f)
+		f.Close()
+	}
+	defer func() {
+		if f, err := os.Create(historyFile); err == nil {
+			r.line.WriteHistory(f)
+			f.Close()
+		} else {
+			jww.WARN.Println("Could not save REPL history:", err)
+		}
+	}()
+
+	fmt.Println("Type \":quit\" to quit, or \":help\" for more")
 
 	for {
-		fmt.Print("> ")
-		input, err := inputreader.ReadString('\n') // this will prompt the user for input
+		input, err := r.readCommand()
+		if err == io.EOF {
+			// Ctrl-D
+			fmt.Println()
+			break
+		}
+		if err == liner.ErrPromptAborted {
+			// Ctrl-C throws away what's been typed, like in a shell.
+			continue
+		}
+		if err != nil {
+			fmt.Println(err)
+			break
+		}
 
+		if input == "" {
+			continue
+		}
+		r.line.AppendHistory(input)
+
+		if input == "exit" || strings.HasPrefix(input, ":") {
+			if quit := r.meta(input); quit {
+				break
+			}
+			continue
+		}
+
+		r.send(input)
+	}
+
+	r.client.Close()
+}
+
+// readCommand reads a command, joining lines ending in \ onto the next.
+func (r *repl) readCommand() (string, error) {
+	prompt := "> "
+	if r.profile != "" {
+		prompt = r.profile + "> "
+	}
+
+	var parts []string
+	for {
+		input, err := r.line.Prompt(prompt)
 		if err != nil {
+			return "", err
+		}
+
+		input = strings.TrimSpace(input)
+		if !strings.HasSuffix(input, "\\") {
+			return strings.Join(append(parts, input), " "), nil
+		}
+
+		parts = append(parts, strings.TrimSpace(strings.TrimSuffix(input, "\\")))
+		prompt = strings.Repeat(".", len(prompt)-1) + " "
+	}
+}
+
+// send runs a command on the server and prints the response. Ctrl-C while waiting gives up on it.
+func (r *repl) send(command string) {
+	command, unchecked := cmdtree.StripUnchecked(command)
+	if r.validate && !unchecked {
+		if err := r.tree.Validate(command); err != nil {
 			fmt.Println(err)
-			os.Exit(1)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
 		}
-		if input == "exit\n" {
-			client.Close()
+	}()
+
+	response, err := r.client.SendCommandContext(ctx, command)
+	if err != nil {
+		var te *mcrcon.TimeoutError
+		if errors.As(err, &te) {
+			// The reconnect policy will pick the connection back up on the next command.
+			fmt.Println("Interrupted.")
 			return
 		}
+		fmt.Println("Error:", err)
+		return
+	}
 
-		cmdResponse, rUserErr := client.SendCommand(input)
+	printResponse(r.format, command, response, rcmd.Check(command, response))
+}
 
-		if rUserErr != nil {
-			fmt.Println("FATAL: ", rUserErr)
+// meta handles a line for minecontrol itself rather than the server. It returns true if it's time to quit.
+func (r *repl) meta(input string) bool {
+	args := strings.Fields(input)
+
+	switch args[0] {
+	case "exit", ":quit", ":q", ":exit":
+		return true
+
+	case ":help":
+		fmt.Println(replHelp)
+
+	case ":reconnect":
+		// The old client is only closed once there's a new one, since a closed client can't reconnect by itself.
+		old := r.client
+		if err := r.connect(); err != nil {
+			fmt.Println(err)
+			return false
 		}
+		old.Close()
+		fmt.Println("Reconnected.")
 
-		fmt.Println(cmdResponse)
+	case ":server":
+		if len(args) != 2 {
+			fmt.Println("Usage: :server <profile>")
+			return false
+		}
+		if err := r.switchServer(args[1]); err != nil {
+			fmt.Println(err)
+			return false
+		}
+		fmt.Printf("Connected to %s.\n", args[1])
+
+	case ":format":
+		if len(args) != 2 || !validOutputFormat(args[1]) {
+			fmt.Println("Usage: :format text|json|raw")
+			return false
+		}
+		r.format = args[1]
+
+	case ":refresh":
+		r.tree = loadCommandTree(r.client, r.address, r.port, true)
+		fmt.Printf("Found %d commands.\n", len(r.tree.Commands()))
+
+	case ":validate":
+		if len(args) != 2 || (args[1] != "on" && args[1] != "off") {
+			fmt.Println("Usage: :validate on|off")
+			return false
+		}
+		r.validate = args[1] == "on"
+
+	default:
+		fmt.Printf("Unknown command %q, try :help\n", args[0])
 	}
 
+	return false
+}
+
+// connect opens a connection to the current server, replacing any that's already open.
+func (r *repl) connect() error {
+	client, err := mcrcon.NewClient(r.address, r.port, r.password)
+	if err != nil {
+		return err
+	}
+	client.SetReconnectPolicy(mcrcon.DefaultReconnectPolicy())
+
+	r.client = client
+	r.tree = loadCommandTree(client, r.address, r.port, false)
+	r.playersAt = time.Time{}
+	return nil
+}
+
+// switchServer connects to the server described by profiles.<name> in the config file. Settings the profile leaves out
+// are taken from the rcon section, except the password, which is asked for.
+func (r *repl) switchServer(name string) error {
+	key := "profiles." + name
+	if !viper.IsSet(key) {
+		return fmt.Errorf("No profile called %q in the config file.", name)
+	}
+
+	address, port := viper.GetString("rcon.address"), viper.GetInt("rcon.port")
+	if viper.IsSet(key + ".address") {
+		address = viper.GetString(key + ".address")
+	}
+	if viper.IsSet(key + ".port") {
+		port = viper.GetInt(key + ".port")
+	}
+
+	password := viper.GetString(key + ".password")
+	if password == "" {
+		var err error
+		if password, err = r.line.PasswordPrompt(fmt.Sprintf("RCON password for %s: ", name)); err != nil {
+			return err
+		}
+	}
+
+	old := *r
+	r.address, r.port, r.password = address, port, password
+	if err := r.connect(); err != nil {
+		r.address, r.port, r.password = old.address, old.port, old.password
+		return err
+	}
+	old.client.Close()
+	r.profile = name
+
+	return nil
+}
+
+// historyFile is where the REPL keeps its history.
+func (r *repl) historyFile() string {
+	if path := viper.GetString("repl.history"); path != "" {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".minecontrol_history"
+	}
+	return filepath.Join(home, ".minecontrol_history")
 }
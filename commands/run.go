@@ -1,41 +1,147 @@
 package commands
 
 import (
+	"bufio"
+	"errors"
 	"fmt"
 	"github.com/joshproehl/minecontrol/mcrcon"
+	"github.com/joshproehl/minecontrol/mcrcon/cmdtree"
+	rcmd "github.com/joshproehl/minecontrol/mcrcon/commands"
 	"github.com/spf13/cobra"
 	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+	"io"
 	"os"
+	"strings"
 )
 
 var runCmd = &cobra.Command{
-	Use:   "run [command]",
+	Use:   "run [flags] [command]",
 	Short: "Run the provided command on the server, then exit",
-	Long:  `Sometimes you don't want a REPL, you just want to run a single command. This is how.`,
+	Long: `Sometimes you don't want a REPL, you just want to run a single command. This is how.
+
+Flags go before the command. Everything from the command's first word on is sent to the server as it is, so arguments
+starting with a dash don't need quoting or a "--": "minecontrol run -o json tp @p ~ -1 ~".
+
+Give "-" as the command to read commands from stdin instead, one per line. Blank lines and lines starting with # are
+skipped, so a file of commands can be run with "minecontrol run - < commands.txt". Set the RCON password with -P or
+the config file when doing this, since otherwise the password prompt would read it from stdin too.
+
+The exit status is 0 if everything worked, 2 if the server couldn't be reached, 3 if the RCON password was wrong, and 4
+if the server reported a command as unknown or failed. Commands are checked against the server's help before they're
+sent, so a misspelt command is reported with suggestions and never reaches the server. Start a command with ! to send
+it unchecked, for one the server has but its cached help doesn't know about yet (or use --refreshCommands).`,
 	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			jww.FATAL.Println("No command given.")
+			os.Exit(exitError)
+		}
+
+		var commands []string
+		if len(args) == 1 && args[0] == "-" {
+			var err error
+			if commands, err = readCommands(os.Stdin); err != nil {
+				jww.FATAL.Println("Could not read commands from stdin:", err)
+				os.Exit(exitError)
+			}
+		} else {
+			commands = []string{strings.Join(args, " ")}
+		}
+
+		os.Exit(runCommands(viper.GetString("rcon.address"), viper.GetInt("rcon.port"), viper.GetString("rcon.password"), commands))
 	},
 }
 
-// runCommand takes the options passed in from the command line, prints the output of the command, and terminates.
-func runCommand(address string, port int, password string, command string) {
-	client, err := mcrcon.NewClient(address, port, password)
+var fvRunOutput string
+var fvRunValidate bool
+
+func init() {
+	runCmd.Flags().StringVarP(&fvRunOutput, "output", "o", "text", "How to print responses: text, json or raw")
+	runCmd.Flags().BoolVar(&fvRunValidate, "validate", true, "Check commands against the server's help before sending them, to catch typos")
+
+	// Stop at the command, so negative coordinates and the like aren't taken for flags.
+	runCmd.Flags().SetInterspersed(false)
+}
+
+// Exit statuses, so scripts can tell what went wrong.
+const (
+	exitOK            = 0
+	exitError         = 1
+	exitConnection    = 2
+	exitAuth          = 3
+	exitCommandFailed = 4
+)
+
+// runCommands runs each command in turn and prints its response, returning the exit status. A command the server
+// reports as failed doesn't stop the rest from running, but losing the connection does.
+func runCommands(address string, port int, password string, commands []string) int {
+	if !validOutputFormat(fvRunOutput) {
+		jww.FATAL.Println(fmt.Sprintf("Unknown output format %q, use text, json or raw.", fvRunOutput))
+		return exitError
+	}
+
+	jww.DEBUG.Println(fmt.Sprintf("Connecting to %s:%d", address, port))
 
+	client, err := mcrcon.NewClient(address, port, password)
 	if err != nil {
 		jww.FATAL.Println(err)
-		os.Exit(1)
+		if errors.Is(err, mcrcon.ErrAuthFailed) {
+			return exitAuth
+		}
+		return exitConnection
 	}
+	defer client.Close()
+
+	var tree *cmdtree.Tree
+	if fvRunValidate {
+		tree = loadCommandTree(client, address, port, false)
+	}
+
+	status := exitOK
 
-	jww.DEBUG.Println(fmt.Sprintf("Connecting to %s:%d, with password %s, using command %s", address, port, password, command))
-	fmt.Println("Executing command: ", command)
+	for _, command := range commands {
+		command, unchecked := cmdtree.StripUnchecked(command)
+		if err := tree.Validate(command); err != nil && !unchecked {
+			printResponse(fvRunOutput, command, err.Error(), err)
+			status = exitCommandFailed
+			continue
+		}
 
-	cmdResponse, rUserErr := client.SendCommand(command)
+		jww.INFO.Println("Executing command: ", command)
 
-	if rUserErr != nil {
-		jww.FATAL.Println(rUserErr)
+		response, err := client.SendCommand(command)
+		if err != nil {
+			jww.FATAL.Println(err)
+			if errors.Is(err, mcrcon.ErrAuthFailed) {
+				return exitAuth
+			}
+			return exitConnection
+		}
+		jww.DEBUG.Println("Response: ", response)
+
+		cmdErr := rcmd.Check(command, response)
+		if cmdErr != nil {
+			status = exitCommandFailed
+		}
+
+		printResponse(fvRunOutput, command, response, cmdErr)
 	}
 
-	jww.DEBUG.Println("Response: ", cmdResponse)
-	fmt.Println(cmdResponse)
+	return status
+}
+
+// readCommands reads commands one per line, skipping blank lines and # comments.
+func readCommands(r io.Reader) ([]string, error) {
+	var commands []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		commands = append(commands, line)
+	}
 
-	client.Close()
+	return commands, scanner.Err()
 }
@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+var setUpOnce sync.Once
+
+// setUp builds the command tree the way GetGoing does, minus the config file.
+func setUp() {
+	setUpOnce.Do(func() {
+		addCommands()
+		addFlags()
+	})
+}
+
+func TestRunArgs(t *testing.T) {
+	setUp()
+
+	tests := []struct {
+		args     []string
+		want     []string
+		output   string
+		password string
+	}{
+		{[]string{"run", "tp", "@p", "~", "-1", "~"}, []string{"tp", "@p", "~", "-1", "~"}, "text", ""},
+		{[]string{"run", "-o", "json", "-P", "pw", "tp", "@p", "~", "-1", "~"}, []string{"tp", "@p", "~", "-1", "~"}, "json", "pw"},
+		{[]string{"run", "--output=raw", "--", "say", "-o"}, []string{"say", "-o"}, "raw", ""},
+		{[]string{"run", "say", "--output", "json"}, []string{"say", "--output", "json"}, "text", ""},
+		{[]string{"run", "-"}, []string{"-"}, "text", ""},
+	}
+
+	for _, tt := range tests {
+		name := strings.Join(tt.args, " ")
+		fvRunOutput, fvPassword = "text", ""
+
+		cmd, args, err := mcCmd.Find(tt.args)
+		if err != nil || cmd != runCmd {
+			t.Errorf("%s: found %v, %v", name, cmd.Name(), err)
+			continue
+		}
+		if err := cmd.ParseFlags(args); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+
+		if got := cmd.Flags().Args(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: args %q, want %q", name, got, tt.want)
+		}
+		if fvRunOutput != tt.output || fvPassword != tt.password {
+			t.Errorf("%s: output %q and password %q, want %q and %q", name, fvRunOutput, fvPassword, tt.output, tt.password)
+		}
+	}
+}
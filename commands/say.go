@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"fmt"
+	"github.com/joshproehl/minecontrol/chat"
+	"github.com/joshproehl/minecontrol/formatting"
+	"github.com/joshproehl/minecontrol/mcrcon"
+	"github.com/spf13/cobra"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+	"os"
+	"strings"
+)
+
+var sayCmd = &cobra.Command{
+	Use:   "say [message]",
+	Short: "Broadcast a message to the players on the server",
+	Long: `Send a message to everyone's chat. With --rich the message may use & or § formatting codes, like
+"&6Restarting in &l5&r&6 minutes", and is sent with tellraw instead of say, so it isn't prefixed with [Server].`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 0 {
+			jww.FATAL.Println("Nothing to say.")
+			os.Exit(1)
+		}
+
+		message := strings.Join(args, " ")
+		command := "say " + message
+		if fvSayRich {
+			c := chat.FromLegacy(formatting.TranslateAlternateCodes('&', message))
+			command = chat.Tellraw(fvSayTarget, c)
+		}
+
+		sayCommand(viper.GetString("rcon.address"), viper.GetInt("rcon.port"), viper.GetString("rcon.password"), command)
+	},
+}
+
+var fvSayRich bool
+var fvSayTarget string
+
+func init() {
+	sayCmd.Flags().BoolVar(&fvSayRich, "rich", false, "Treat & and § codes in the message as formatting, and send it with tellraw")
+	sayCmd.Flags().StringVar(&fvSayTarget, "target", "@a", "Who gets a --rich message, as a player name or selector")
+}
+
+// sayCommand sends the broadcast command built by sayCmd.
+func sayCommand(address string, port int, password string, command string) {
+	client, err := mcrcon.NewClient(address, port, password)
+	if err != nil {
+		jww.FATAL.Println(err)
+		os.Exit(1)
+	}
+	defer client.Close()
+
+	jww.DEBUG.Println("Sending: ", command)
+
+	response, err := client.SendCommand(command)
+	if err != nil {
+		jww.FATAL.Println(err)
+		os.Exit(1)
+	}
+
+	// say and tellraw answer with nothing on success, so anything else is the server complaining.
+	if response != "" {
+		fmt.Println(renderResponse(response))
+	}
+}
@@ -3,7 +3,9 @@ package commands
 import (
 	"github.com/joshproehl/minecontrol/mcrcon/restServer"
 	"github.com/spf13/cobra"
+	jww "github.com/spf13/jwalterweatherman"
 	"github.com/spf13/viper"
+	"os"
 )
 
 var serverCmd = &cobra.Command{
@@ -13,23 +15,48 @@ var serverCmd = &cobra.Command{
 By default the server will be available at http://127.0.0.0.1:7767`,
 	Run: func(cmd *cobra.Command, args []string) {
 		c := restServer.ServerConfig{
-			RCON_address:  viper.GetString("rcon.address"),
-			RCON_port:     viper.GetInt("rcon.port"),
-			RCON_password: viper.GetString("rcon.password"),
-			Username:      viper.GetString("server.username"),
-			Password:      viper.GetString("server.password"),
-			Port:          viper.GetInt("server.port"),
+			RCON_address:     viper.GetString("rcon.address"),
+			RCON_port:        viper.GetInt("rcon.port"),
+			RCON_password:    viper.GetString("rcon.password"),
+			RCON_connections: viper.GetInt("rcon.connections"),
+			Query_port:       viper.GetInt("query.port"),
+			Game_port:        viper.GetInt("game.port"),
+			Minecraft_dir:    viper.GetString("minecraft.dir"),
+			Cmdtree_cache:    viper.GetString("cmdtree.cache"),
+			Cmdtree_max_age:  viper.GetDuration("cmdtree.maxAge"),
+			Cmdtree_refresh:  viper.GetBool("cmdtree.refresh"),
+			Username:         viper.GetString("server.username"),
+			Password:         viper.GetString("server.password"),
+			Users:            viper.GetStringMapString("server.users"),
+			Tokens_file:      viper.GetString("server.tokens"),
+			Public_gui:       viper.GetBool("server.publicGUI"),
+			Port:             viper.GetInt("server.port"),
 		}
 
-		restServer.NewRestServer(&c)
+		if err := viper.UnmarshalKey("policy", &c.Policy); err != nil {
+			jww.FATAL.Println("Could not read the policy section of the config file:", err)
+			os.Exit(1)
+		}
+
+		if err := restServer.NewRestServer(&c); err != nil {
+			jww.FATAL.Println(err)
+			os.Exit(1)
+		}
 	},
 }
 
 func init() {
 	serverCmd.Flags().Int("serverPort", 7767, "Port to run the REST server on")
+	serverCmd.Flags().Int("rconConnections", 4, "Most RCON connections to open at once for serving requests")
+	serverCmd.Flags().String("minecraftDir", "", "The Minecraft server's directory, for reading files like ops.json")
 	serverCmd.Flags().String("serverUsername", "", "HTTP Basic auth username that the REST server will require")
-	serverCmd.Flags().String("serverPassword", "", "HTTP Basic auth password that the REST server will require")
+	serverCmd.Flags().String("serverPassword", "", "HTTP Basic auth password that the REST server will require, as is or bcrypt hashed")
+	serverCmd.Flags().Bool("publicGUI", false, "Serve the GUI's pages without asking for a password (the API still needs one)")
 	viper.BindPFlag("server.port", serverCmd.Flags().Lookup("serverPort"))
+	viper.BindPFlag("rcon.connections", serverCmd.Flags().Lookup("rconConnections"))
+	viper.BindPFlag("minecraft.dir", serverCmd.Flags().Lookup("minecraftDir"))
 	viper.BindPFlag("server.username", serverCmd.Flags().Lookup("serverUsername"))
 	viper.BindPFlag("server.password", serverCmd.Flags().Lookup("serverPassword"))
+	viper.BindPFlag("server.publicGUI", serverCmd.Flags().Lookup("publicGUI"))
+	viper.SetDefault("server.tokens", "minecontrol-tokens.json")
 }
@@ -0,0 +1,113 @@
+package commands
+
+import (
+	"fmt"
+	"github.com/howeyc/gopass"
+	"github.com/joshproehl/minecontrol/mcrcon/restServer"
+	"github.com/spf13/cobra"
+	jww "github.com/spf13/jwalterweatherman"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/bcrypt"
+	"os"
+	"text/tabwriter"
+)
+
+var tokenCmd = &cobra.Command{
+	Use:   "token",
+	Short: "Manage API tokens for the REST server",
+	Long: `API tokens let programs use the REST server without a username and password. Send one in an
+"Authorization: Bearer <token>" header. Tokens are kept, hashed, in the file set by server.tokens in the config file,
+and the server notices when they're created or revoked without needing a restart. The exception is the first token, if
+there are no users either: the server started without authentication, and has to be restarted to turn it on.`,
+}
+
+var tokenCreateCmd = &cobra.Command{
+	Use:   "create [name]",
+	Short: "Create a new API token",
+	Long:  `Create a new API token, with a name to remember what it's for. The token is only shown this once.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			jww.FATAL.Println("Give the token a name, e.g. \"minecontrol token create dashboard\".")
+			os.Exit(1)
+		}
+
+		token, info, err := openTokenStore().Create(args[0])
+		if err != nil {
+			jww.FATAL.Println("Could not create token:", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Created token %s (%s). Keep it somewhere safe, it can't be shown again:\n", info.ID, info.Name)
+		fmt.Println(token)
+	},
+}
+
+var tokenListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the API tokens",
+	Run: func(cmd *cobra.Command, args []string) {
+		tokens, err := openTokenStore().List()
+		if err != nil {
+			jww.FATAL.Println("Could not read tokens:", err)
+			os.Exit(1)
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tNAME\tCREATED")
+		for _, t := range tokens {
+			fmt.Fprintf(w, "%s\t%s\t%s\n", t.ID, t.Name, t.Created.Local().Format("2006-01-02 15:04"))
+		}
+		w.Flush()
+	},
+}
+
+var tokenRevokeCmd = &cobra.Command{
+	Use:   "revoke [id or name]",
+	Short: "Revoke an API token, so it can't be used any more",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) != 1 {
+			jww.FATAL.Println("Give the ID or name of the token to revoke.")
+			os.Exit(1)
+		}
+
+		if err := openTokenStore().Revoke(args[0]); err != nil {
+			jww.FATAL.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println("Revoked.")
+	},
+}
+
+var hashPasswordCmd = &cobra.Command{
+	Use:   "hash-password",
+	Short: "Hash a password for the REST server's config",
+	Long: `Prompt for a password and print a bcrypt hash of it, which can go in server.password or server.users in the
+config file in place of the password itself.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Printf("Password: ")
+		password := gopass.GetPasswd()
+
+		hash, err := bcrypt.GenerateFromPassword(password, bcrypt.DefaultCost)
+		if err != nil {
+			jww.FATAL.Println(err)
+			os.Exit(1)
+		}
+		fmt.Println(string(hash))
+	},
+}
+
+func init() {
+	tokenCmd.AddCommand(tokenCreateCmd)
+	tokenCmd.AddCommand(tokenListCmd)
+	tokenCmd.AddCommand(tokenRevokeCmd)
+}
+
+// openTokenStore opens the token file the REST server uses.
+func openTokenStore() *restServer.TokenStore {
+	ts, err := restServer.LoadTokenStore(viper.GetString("server.tokens"))
+	if err != nil {
+		jww.FATAL.Println(err)
+		os.Exit(1)
+	}
+	return ts
+}
@@ -0,0 +1,366 @@
+// formatting renders text containing Minecraft's legacy § formatting codes, as found in command responses from Spigot
+// and Paper, MOTDs and plugin output. It can turn them into ANSI escapes for terminals, HTML for browsers, or strip them.
+package formatting
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+)
+
+/*
+From the Minecraft wiki, Formatting codes:
+
+	§0 black        §4 dark_red      §8 dark_gray   §c red
+	§1 dark_blue    §5 dark_purple   §9 blue        §d light_purple
+	§2 dark_green   §6 gold          §a green       §e yellow
+	§3 dark_aqua    §7 gray          §b aqua        §f white
+
+	§k obfuscated   §l bold   §m strikethrough   §n underline   §o italic   §r reset
+
+A colour code also resets any formatting before it. Spigot writes hex colours as §x followed by the six hex digits,
+each with its own §, e.g. §x§f§f§a§a§0§0.
+*/
+
+// Format is a way of rendering formatted text.
+type Format string
+
+const (
+	Plain Format = "plain" // Codes removed
+	ANSI  Format = "ansi"  // Codes turned into ANSI terminal escapes
+	HTML  Format = "html"  // Text escaped, codes turned into styled spans
+	Raw   Format = "raw"   // Left exactly as it was
+)
+
+// ParseFormat turns a format name, as given on the command line or in a query string, into a Format.
+func ParseFormat(name string) (Format, error) {
+	switch f := Format(strings.ToLower(name)); f {
+	case Plain, ANSI, HTML, Raw:
+		return f, nil
+	}
+	return "", fmt.Errorf("Unknown format %q, expected one of plain, ansi, html or raw", name)
+}
+
+// Style is the formatting that applies to a run of text.
+type Style struct {
+	// Color is a colour name as used in JSON text components, like "gold", or a hex colour like "#ffaa00".
+	// Empty means the default colour.
+	Color         string
+	Bold          bool
+	Italic        bool
+	Underlined    bool
+	Strikethrough bool
+	Obfuscated    bool
+}
+
+// Segment is a run of text with the same style.
+type Segment struct {
+	Text  string
+	Style Style
+}
+
+// Each colour code's name, with the RGB value the game uses for it and the nearest ANSI foreground colour.
+var colors = map[byte]struct {
+	name string
+	rgb  string
+	ansi int
+}{
+	'0': {"black", "#000000", 30},
+	'1': {"dark_blue", "#0000aa", 34},
+	'2': {"dark_green", "#00aa00", 32},
+	'3': {"dark_aqua", "#00aaaa", 36},
+	'4': {"dark_red", "#aa0000", 31},
+	'5': {"dark_purple", "#aa00aa", 35},
+	'6': {"gold", "#ffaa00", 33},
+	'7': {"gray", "#aaaaaa", 37},
+	'8': {"dark_gray", "#555555", 90},
+	'9': {"blue", "#5555ff", 94},
+	'a': {"green", "#55ff55", 92},
+	'b': {"aqua", "#55ffff", 96},
+	'c': {"red", "#ff5555", 91},
+	'd': {"light_purple", "#ff55ff", 95},
+	'e': {"yellow", "#ffff55", 93},
+	'f': {"white", "#ffffff", 97},
+}
+
+// colorCodes maps colour names back to their codes.
+var colorCodes = make(map[string]byte)
+
+func init() {
+	for code, c := range colors {
+		colorCodes[c.name] = code
+	}
+}
+
+// Parse splits s into runs of identically styled text. Empty runs are left out.
+func Parse(s string) []Segment {
+	var segments []Segment
+	var style Style
+	var text strings.Builder
+
+	flush := func() {
+		if text.Len() > 0 {
+			segments = append(segments, Segment{Text: text.String(), Style: style})
+			text.Reset()
+		}
+	}
+
+	for i := 0; i < len(s); {
+		if !strings.HasPrefix(s[i:], "§") || i+len("§") >= len(s) {
+			text.WriteByte(s[i])
+			i++
+			continue
+		}
+
+		code := lower(s[i+len("§")])
+		i += len("§") + 1
+
+		if c, ok := colors[code]; ok {
+			flush()
+			style = Style{Color: c.name}
+			continue
+		}
+
+		switch code {
+		case 'x':
+			if hex, n := readHex(s[i:]); n > 0 {
+				flush()
+				style = Style{Color: "#" + hex}
+				i += n
+			}
+		case 'k':
+			flush()
+			style.Obfuscated = true
+		case 'l':
+			flush()
+			style.Bold = true
+		case 'm':
+			flush()
+			style.Strikethrough = true
+		case 'n':
+			flush()
+			style.Underlined = true
+		case 'o':
+			flush()
+			style.Italic = true
+		case 'r':
+			flush()
+			style = Style{}
+		default:
+			// Not a code we know, so the game would show it as is.
+			text.WriteString(s[i-len("§")-1 : i])
+		}
+	}
+	flush()
+
+	return segments
+}
+
+// readHex reads the six "§<digit>" pairs after a §x. It returns the digits and how many bytes they took up, or 0 if
+// they aren't all there.
+func readHex(s string) (string, int) {
+	var hex strings.Builder
+	n := 0
+	for d := 0; d < 6; d++ {
+		if !strings.HasPrefix(s[n:], "§") || n+len("§") >= len(s) {
+			return "", 0
+		}
+		c := lower(s[n+len("§")])
+		if !strings.ContainsRune("0123456789abcdef", rune(c)) {
+			return "", 0
+		}
+		hex.WriteByte(c)
+		n += len("§") + 1
+	}
+	return hex.String(), n
+}
+
+func lower(c byte) byte {
+	if c >= 'A' && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+// TranslateAlternateCodes swaps alt for § wherever it's followed by a formatting code, so text typed with the
+// friendlier "&c" style codes can be used, e.g. TranslateAlternateCodes('&', "&6Gold &lbold"). Other uses of alt are left
+// alone.
+func TranslateAlternateCodes(alt byte, s string) string {
+	var out strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == alt && i+1 < len(s) && strings.IndexByte("0123456789abcdefklmnorx", lower(s[i+1])) >= 0 {
+			out.WriteString("§")
+			continue
+		}
+		out.WriteByte(s[i])
+	}
+	return out.String()
+}
+
+// Codes returns the formatting codes that switch to st from any other style. They start with the colour, or §r for the
+// default colour, since either of those clears whatever came before.
+func (st Style) Codes() string {
+	var b strings.Builder
+
+	if code, ok := colorCodes[st.Color]; ok {
+		b.WriteString("§")
+		b.WriteByte(code)
+	} else if _, _, _, ok := parseHex(st.Color); ok {
+		b.WriteString("§x")
+		for _, d := range strings.ToLower(st.Color[1:]) {
+			b.WriteString("§")
+			b.WriteRune(d)
+		}
+	} else {
+		b.WriteString("§r")
+	}
+
+	if st.Obfuscated {
+		b.WriteString("§k")
+	}
+	if st.Bold {
+		b.WriteString("§l")
+	}
+	if st.Strikethrough {
+		b.WriteString("§m")
+	}
+	if st.Underlined {
+		b.WriteString("§n")
+	}
+	if st.Italic {
+		b.WriteString("§o")
+	}
+
+	return b.String()
+}
+
+// Render renders s in the given format.
+func Render(s string, f Format) string {
+	switch f {
+	case Plain:
+		return Strip(s)
+	case ANSI:
+		return ToANSI(s)
+	case HTML:
+		return ToHTML(s)
+	}
+	return s
+}
+
+// Strip removes all formatting codes from s.
+func Strip(s string) string {
+	var b strings.Builder
+	for _, seg := range Parse(s) {
+		b.WriteString(seg.Text)
+	}
+	return b.String()
+}
+
+// ToANSI replaces formatting codes with ANSI escape sequences. Hex colours use 24-bit colour escapes.
+func ToANSI(s string) string {
+	var b strings.Builder
+	styled := false
+
+	for _, seg := range Parse(s) {
+		codes := ansiCodes(seg.Style)
+		if codes != "" || styled {
+			b.WriteString("\x1b[0")
+			b.WriteString(codes)
+			b.WriteString("m")
+			styled = codes != ""
+		}
+		b.WriteString(seg.Text)
+	}
+	if styled {
+		b.WriteString("\x1b[0m")
+	}
+
+	return b.String()
+}
+
+func ansiCodes(st Style) string {
+	var codes []string
+
+	if code, ok := colorCodes[st.Color]; ok {
+		codes = append(codes, strconv.Itoa(colors[code].ansi))
+	} else if r, g, bl, ok := parseHex(st.Color); ok {
+		codes = append(codes, fmt.Sprintf("38;2;%d;%d;%d", r, g, bl))
+	}
+	if st.Bold {
+		codes = append(codes, "1")
+	}
+	if st.Italic {
+		codes = append(codes, "3")
+	}
+	if st.Underlined {
+		codes = append(codes, "4")
+	}
+	if st.Strikethrough {
+		codes = append(codes, "9")
+	}
+
+	if len(codes) == 0 {
+		return ""
+	}
+	return ";" + strings.Join(codes, ";")
+}
+
+func parseHex(color string) (r, g, b uint8, ok bool) {
+	if len(color) != 7 || color[0] != '#' {
+		return 0, 0, 0, false
+	}
+	n, err := strconv.ParseUint(color[1:], 16, 32)
+	if err != nil {
+		return 0, 0, 0, false
+	}
+	return uint8(n >> 16), uint8(n >> 8), uint8(n), true
+}
+
+// ToHTML escapes s for use in HTML and replaces formatting codes with inline styled spans. The only markup in the
+// result is the spans, and their styles only ever come from the fixed palette or validated hex colours, so it's safe
+// to insert server output into a page this way.
+func ToHTML(s string) string {
+	var b strings.Builder
+
+	for _, seg := range Parse(s) {
+		text := html.EscapeString(seg.Text)
+		css := cssStyle(seg.Style)
+		if css == "" {
+			b.WriteString(text)
+			continue
+		}
+		fmt.Fprintf(&b, `<span style="%s">%s</span>`, css, text)
+	}
+
+	return b.String()
+}
+
+func cssStyle(st Style) string {
+	var rules []string
+
+	if code, ok := colorCodes[st.Color]; ok {
+		rules = append(rules, "color:"+colors[code].rgb)
+	} else if _, _, _, ok := parseHex(st.Color); ok {
+		rules = append(rules, "color:"+st.Color)
+	}
+	if st.Bold {
+		rules = append(rules, "font-weight:bold")
+	}
+	if st.Italic {
+		rules = append(rules, "font-style:italic")
+	}
+
+	var decorations []string
+	if st.Underlined {
+		decorations = append(decorations, "underline")
+	}
+	if st.Strikethrough {
+		decorations = append(decorations, "line-through")
+	}
+	if len(decorations) > 0 {
+		rules = append(rules, "text-decoration:"+strings.Join(decorations, " "))
+	}
+
+	return strings.Join(rules, ";")
+}
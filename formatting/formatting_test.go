@@ -0,0 +1,190 @@
+package formatting
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want []Segment
+	}{
+		{"plain", "hello", []Segment{{Text: "hello"}}},
+		{"empty", "", nil},
+		{"colour", "§6gold", []Segment{{Text: "gold", Style: Style{Color: "gold"}}}},
+		{"upper case code", "§Cred", []Segment{{Text: "red", Style: Style{Color: "red"}}}},
+		{
+			"decorations add up",
+			"§lbold §oand italic",
+			[]Segment{{Text: "bold ", Style: Style{Bold: true}}, {Text: "and italic", Style: Style{Bold: true, Italic: true}}},
+		},
+		{
+			"reset clears everything",
+			"§c§l§nloud§r quiet",
+			[]Segment{{Text: "loud", Style: Style{Color: "red", Bold: true, Underlined: true}}, {Text: " quiet"}},
+		},
+		{
+			"colour clears decorations",
+			"§l§mstruck§9blue",
+			[]Segment{{Text: "struck", Style: Style{Bold: true, Strikethrough: true}}, {Text: "blue", Style: Style{Color: "blue"}}},
+		},
+		{
+			"decoration after colour keeps it",
+			"§a§kmagic",
+			[]Segment{{Text: "magic", Style: Style{Color: "green", Obfuscated: true}}},
+		},
+		{"hex colour", "§x§f§f§A§a§0§0orange", []Segment{{Text: "orange", Style: Style{Color: "#ffaa00"}}}},
+		{
+			"hex colour clears decorations",
+			"§lbold§x§1§2§3§4§5§6hex",
+			[]Segment{{Text: "bold", Style: Style{Bold: true}}, {Text: "hex", Style: Style{Color: "#123456"}}},
+		},
+		{"short hex colour is ignored", "§x§f§fab", []Segment{{Text: "ab", Style: Style{Color: "white"}}}},
+		{"unknown code is kept", "§zoo", []Segment{{Text: "§zoo"}}},
+		{"dangling §", "end§", []Segment{{Text: "end§"}}},
+		{"only §", "§", []Segment{{Text: "§"}}},
+		{"empty runs left out", "§6§c§r§lx", []Segment{{Text: "x", Style: Style{Bold: true}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.in); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %#v, want %#v", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStrip(t *testing.T) {
+	tests := map[string]string{
+		"§6Gold §lbold§r plain":   "Gold bold plain",
+		"§x§f§f§a§a§0§0hex":       "hex",
+		"50§ off":                 "50§ off",
+		"trailing§":               "trailing§",
+		"§zunknown":               "§zunknown",
+		"no codes at all":         "no codes at all",
+		"§cUnknown or incomplete": "Unknown or incomplete",
+	}
+	for in, want := range tests {
+		if got := Strip(in); got != want {
+			t.Errorf("Strip(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestToANSI(t *testing.T) {
+	tests := map[string]string{
+		"plain":                "plain",
+		"§6gold":               "\x1b[0;33mgold\x1b[0m",
+		"§c§lred bold§r plain": "\x1b[0;91;1mred bold\x1b[0m plain",
+		"§lbold§6gold":         "\x1b[0;1mbold\x1b[0;33mgold\x1b[0m",
+		"§x§f§f§a§a§0§0hex":    "\x1b[0;38;2;255;170;0mhex\x1b[0m",
+		"trailing§":            "trailing§",
+	}
+	for in, want := range tests {
+		if got := ToANSI(in); got != want {
+			t.Errorf("ToANSI(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestToHTML(t *testing.T) {
+	tests := map[string]string{
+		"plain":              "plain",
+		"§6gold":             `<span style="color:#ffaa00">gold</span>`,
+		"§l§ntitle§r & more": `<span style="font-weight:bold;text-decoration:underline">title</span> &amp; more`,
+		"§x§f§f§a§a§0§0hex":  `<span style="color:#ffaa00">hex</span>`,
+		"trailing§":          "trailing§",
+		`a "quoted" 'MOTD'`:  "a &#34;quoted&#34; &#39;MOTD&#39;",
+	}
+	for in, want := range tests {
+		if got := ToHTML(in); got != want {
+			t.Errorf("ToHTML(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestToHTMLEscapesMOTD(t *testing.T) {
+	motd := `§6Welcome<script>alert("hi")</script>§r<img src=x onerror=alert(1)>`
+	got := ToHTML(motd)
+
+	for _, bad := range []string{"<script", "</script", "<img"} {
+		if strings.Contains(got, bad) {
+			t.Errorf("ToHTML left %q in %q", bad, got)
+		}
+	}
+	want := `<span style="color:#ffaa00">Welcome&lt;script&gt;alert(&#34;hi&#34;)&lt;/script&gt;</span>&lt;img src=x onerror=alert(1)&gt;`
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestRender(t *testing.T) {
+	in := "§6<b>"
+	for f, want := range map[Format]string{
+		Plain: "<b>",
+		Raw:   "§6<b>",
+		HTML:  `<span style="color:#ffaa00">&lt;b&gt;</span>`,
+		ANSI:  "\x1b[0;33m<b>\x1b[0m",
+	} {
+		if got := Render(in, f); got != want {
+			t.Errorf("Render(%q, %s) = %q, want %q", in, f, got, want)
+		}
+	}
+}
+
+func TestCodes(t *testing.T) {
+	tests := []struct {
+		style Style
+		want  string
+	}{
+		{Style{}, "§r"},
+		{Style{Color: "gold"}, "§6"},
+		{Style{Color: "#FFAA00", Bold: true}, "§x§f§f§a§a§0§0§l"},
+		{Style{Bold: true, Italic: true, Underlined: true, Strikethrough: true, Obfuscated: true}, "§r§k§l§m§n§o"},
+		{Style{Color: "not a colour"}, "§r"},
+	}
+	for _, tt := range tests {
+		if got := tt.style.Codes(); got != tt.want {
+			t.Errorf("%+v.Codes() = %q, want %q", tt.style, got, tt.want)
+		}
+		// And they parse back to the same style.
+		if segs := Parse(tt.want + "x"); tt.style.Color != "not a colour" && (len(segs) != 1 || !sameStyle(segs[0].Style, tt.style)) {
+			t.Errorf("Parse(%q) = %+v, want style %+v", tt.want+"x", segs, tt.style)
+		}
+	}
+}
+
+func sameStyle(a, b Style) bool {
+	a.Color, b.Color = strings.ToLower(a.Color), strings.ToLower(b.Color)
+	return a == b
+}
+
+func TestParseFormat(t *testing.T) {
+	for _, name := range []string{"plain", "ANSI", "Html", "raw"} {
+		if _, err := ParseFormat(name); err != nil {
+			t.Errorf("ParseFormat(%q): %v", name, err)
+		}
+	}
+	if _, err := ParseFormat("markdown"); err == nil {
+		t.Error("ParseFormat accepted markdown")
+	}
+}
+
+func TestTranslateAlternateCodes(t *testing.T) {
+	tests := map[string]string{
+		"&6Gold &lbold":  "§6Gold §lbold",
+		"Tom & Jerry":    "Tom & Jerry",
+		"&zoo":           "&zoo",
+		"end&":           "end&",
+		"&x&f&f&a&a&0&0": "§x§f§f§a§a§0§0",
+	}
+	for in, want := range tests {
+		if got := TranslateAlternateCodes('&', in); got != want {
+			t.Errorf("TranslateAlternateCodes(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+package cmdtree
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/joshproehl/minecontrol/formatting"
+	"github.com/joshproehl/minecontrol/mcrcon"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// maxHelpPages stops a misbehaving plugin's help from keeping us paging forever.
+const maxHelpPages = 100
+
+// Discover runs "help" on the server, and for Bukkit servers each further page of it, and parses the result.
+func Discover(ctx context.Context, c mcrcon.Commander) (*Tree, error) {
+	help, err := c.SendCommandContext(ctx, "help")
+	if err != nil {
+		return nil, err
+	}
+
+	if m := bukkitIndexRe.FindStringSubmatch(formatting.Strip(help)); m != nil {
+		pages, _ := strconv.Atoi(m[2])
+		for page := 2; page <= pages && page <= maxHelpPages; page++ {
+			more, err := c.SendCommandContext(ctx, fmt.Sprintf("help %d", page))
+			if err != nil {
+				return nil, err
+			}
+			help += "\n" + more
+		}
+	}
+
+	tree := Parse(help)
+	if len(tree.Root.Children) == 0 {
+		return nil, fmt.Errorf("No commands found in the response to help: %q", help)
+	}
+	return tree, nil
+}
+
+// DefaultMaxAge is how long a Cache keeps trees unless told otherwise. A day is long enough to save discovering the
+// commands on every connection, and short enough that new plugins are noticed without anyone having to step in.
+const DefaultMaxAge = 24 * time.Hour
+
+// Cache keeps discovered trees, in memory and optionally on disk, keyed by server address and version. Servers on the
+// same version can still differ in their plugins, so each gets its own tree, and trees are discovered again once they
+// reach MaxAge, so commands from newly added plugins are picked up.
+type Cache struct {
+	// Dir is where trees are saved between runs. If empty they're only kept in memory.
+	Dir string
+
+	// MaxAge is how old a tree can get before it's discovered again. 0 means trees never expire.
+	MaxAge time.Duration
+
+	m     sync.Mutex
+	trees map[string]*Tree
+}
+
+// unsafeFilenameRe matches anything we wouldn't want in a file name made from a server address or version string.
+var unsafeFilenameRe = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// NewCache returns a Cache that saves trees under dir, keeping them for DefaultMaxAge.
+func NewCache(dir string) *Cache {
+	return &Cache{Dir: dir, MaxAge: DefaultMaxAge, trees: make(map[string]*Tree)}
+}
+
+// DefaultCacheDir is where trees are saved unless configured otherwise.
+func DefaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "minecontrol", "cmdtree")
+}
+
+// Load returns the tree for the server at address (host:port) running version, discovering it through c if it isn't
+// cached or has expired. An empty version means it isn't known, in which case the tree is always discovered afresh.
+func (cache *Cache) Load(ctx context.Context, c mcrcon.Commander, server, version string) (*Tree, error) {
+	if version == "" {
+		return cache.discover(ctx, c, server, version)
+	}
+
+	cache.m.Lock()
+	defer cache.m.Unlock()
+
+	key := cacheKey(server, version)
+	if t, ok := cache.trees[key]; ok && cache.fresh(t) {
+		return t, nil
+	}
+
+	if t := cache.read(server, version); t != nil && cache.fresh(t) {
+		cache.trees[key] = t
+		return t, nil
+	}
+
+	return cache.refresh(ctx, c, server, version)
+}
+
+// Refresh discovers the tree for the server again, whether or not there's a cached one, and caches the result.
+func (cache *Cache) Refresh(ctx context.Context, c mcrcon.Commander, server, version string) (*Tree, error) {
+	if version == "" {
+		return cache.discover(ctx, c, server, version)
+	}
+
+	cache.m.Lock()
+	defer cache.m.Unlock()
+
+	return cache.refresh(ctx, c, server, version)
+}
+
+// refresh discovers and caches a tree. Must be called with the cache locked.
+func (cache *Cache) refresh(ctx context.Context, c mcrcon.Commander, server, version string) (*Tree, error) {
+	t, err := cache.discover(ctx, c, server, version)
+	if err != nil {
+		return nil, err
+	}
+	cache.trees[cacheKey(server, version)] = t
+	cache.write(t)
+
+	return t, nil
+}
+
+func (cache *Cache) discover(ctx context.Context, c mcrcon.Commander, server, version string) (*Tree, error) {
+	t, err := Discover(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	t.Server, t.Version, t.Discovered = server, version, time.Now()
+	return t, nil
+}
+
+func (cache *Cache) fresh(t *Tree) bool {
+	return cache.MaxAge <= 0 || time.Since(t.Discovered) < cache.MaxAge
+}
+
+func cacheKey(server, version string) string {
+	return server + " " + version
+}
+
+func (cache *Cache) path(server, version string) string {
+	name := unsafeFilenameRe.ReplaceAllString(server, "_") + "_" + unsafeFilenameRe.ReplaceAllString(version, "_")
+	return filepath.Join(cache.Dir, name+".json")
+}
+
+// read loads a saved tree, returning nil if there isn't a usable one.
+func (cache *Cache) read(server, version string) *Tree {
+	if cache.Dir == "" {
+		return nil
+	}
+
+	b, err := os.ReadFile(cache.path(server, version))
+	if err != nil {
+		return nil
+	}
+
+	var t Tree
+	if err := json.Unmarshal(b, &t); err != nil || t.Server != server || t.Version != version || t.Root == nil {
+		return nil
+	}
+	return &t
+}
+
+// write saves a tree. Failing to is no great loss, it'll just be discovered again next time.
+func (cache *Cache) write(t *Tree) {
+	if cache.Dir == "" {
+		return
+	}
+
+	b, err := json.Marshal(t)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(cache.Dir, 0755); err != nil {
+		return
+	}
+
+	// Write then rename, so a concurrent reader never sees half a file.
+	path := cache.path(t.Server, t.Version)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0644); err != nil {
+		return
+	}
+	os.Rename(tmp, path)
+}
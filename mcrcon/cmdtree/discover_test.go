@@ -0,0 +1,114 @@
+package cmdtree
+
+import (
+	"context"
+	"github.com/joshproehl/minecontrol/mcrcon"
+	"github.com/joshproehl/minecontrol/mcrcon/mcrcontest"
+	"testing"
+	"time"
+)
+
+// newServer starts a fake server whose help lists the given usages.
+func newServer(t *testing.T, help string) (*mcrcontest.Server, *mcrcon.MCRCONClient) {
+	s := mcrcontest.NewServer("secret")
+	t.Cleanup(s.Close)
+	s.HandleResponse("help", help)
+
+	c, err := mcrcon.NewClient(s.Host(), s.Port(), "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(c.Close)
+	return s, c
+}
+
+func helpRuns(s *mcrcontest.Server) int {
+	n := 0
+	for _, cmd := range s.Received() {
+		if cmd == "help" {
+			n++
+		}
+	}
+	return n
+}
+
+func TestCacheKeepsServersApart(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCache(t.TempDir())
+
+	// Two servers on the same version, one with a plugin.
+	vanilla, vc := newServer(t, "/list [uuids]/stop")
+	paper, pc := newServer(t, "/list [uuids]/stop/home [<name>]")
+
+	vt, err := cache.Load(ctx, vc, vanilla.Addr, "1.16.5 (754)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pt, err := cache.Load(ctx, pc, paper.Addr, "1.16.5 (754)")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := vt.Validate("home"); err == nil {
+		t.Error("vanilla server's tree has the plugin's command")
+	}
+	if err := pt.Validate("home"); err != nil {
+		t.Errorf("plugin server's tree rejected its command: %v", err)
+	}
+
+	// A second load, and one from a fresh cache in the same directory, come from the cache.
+	if _, err := cache.Load(ctx, pc, paper.Addr, "1.16.5 (754)"); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := NewCache(cache.Dir).Load(ctx, nil, paper.Addr, "1.16.5 (754)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := saved.Validate("home"); err != nil {
+		t.Errorf("tree read back from disk rejected home: %v", err)
+	}
+	if n := helpRuns(paper); n != 1 {
+		t.Errorf("help was run %d times, want once", n)
+	}
+}
+
+func TestCacheExpiresAndRefreshes(t *testing.T) {
+	ctx := context.Background()
+	cache := NewCache(t.TempDir())
+	cache.MaxAge = 50 * time.Millisecond
+
+	s, c := newServer(t, "/list [uuids]/stop")
+	if _, err := cache.Load(ctx, c, s.Addr, "1.16.5 (754)"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A plugin is added. Until the tree expires the cached one is used.
+	s.HandleResponse("help", "/list [uuids]/stop/home [<name>]")
+	tree, _ := cache.Load(ctx, c, s.Addr, "1.16.5 (754)")
+	if tree.Validate("home") == nil {
+		t.Error("cached tree already knew about home")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	tree, err := cache.Load(ctx, c, s.Addr, "1.16.5 (754)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Validate("home"); err != nil {
+		t.Errorf("expired tree wasn't discovered again: %v", err)
+	}
+
+	// Refresh doesn't wait for it to expire.
+	cache.MaxAge = 0
+	s.HandleResponse("help", "/list [uuids]/stop/home [<name>]/warp <name>")
+	tree, err = cache.Refresh(ctx, c, s.Addr, "1.16.5 (754)")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.Validate("warp spawn"); err != nil {
+		t.Errorf("refreshed tree doesn't know warp: %v", err)
+	}
+	if n := helpRuns(s); n != 3 {
+		t.Errorf("help was run %d times, want 3", n)
+	}
+}
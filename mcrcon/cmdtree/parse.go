@@ -0,0 +1,190 @@
+package cmdtree
+
+import (
+	"github.com/joshproehl/minecontrol/formatting"
+	"regexp"
+	"strings"
+)
+
+var (
+	// The header of a page of Bukkit's help, "--------- Help: Index (1/8) ----------"
+	bukkitIndexRe = regexp.MustCompile(`Help: Index \((\d+)/(\d+)\)`)
+
+	// A command on a page of Bukkit's help, "/ban: Prevents the specified player from using this server"
+	bukkitCommandRe = regexp.MustCompile(`(?m)^/([^\s:]+):`)
+)
+
+// Parse builds a tree from the output of "help". It understands vanilla usage strings, whether one per line or run
+// together as RCON delivers them, and the pages of Bukkit's help index, which only give command names.
+func Parse(help string) *Tree {
+	help = formatting.Strip(help)
+	root := &Node{}
+
+	if bukkitIndexRe.MatchString(help) {
+		for _, m := range bukkitCommandRe.FindAllStringSubmatch(help, -1) {
+			root.child(&Node{Name: m[1], Executable: true, Open: true})
+		}
+		return &Tree{Root: root}
+	}
+
+	for _, usage := range splitUsages(help) {
+		addUsage(root, usage)
+	}
+	return &Tree{Root: root}
+}
+
+// splitUsages separates usage strings. Over RCON vanilla sends them with nothing in between, as in
+// "/advancement (grant|revoke)/attribute <target> ...", so a slash outside of any brackets and followed by a letter
+// starts a new one.
+func splitUsages(help string) []string {
+	var usages []string
+	depth, start := 0, -1
+
+	for i := 0; i < len(help); i++ {
+		switch c := help[i]; {
+		case c == '-' && i+1 < len(help) && help[i+1] == '>':
+			i++ // The arrow in "/tell -> msg" isn't a closing bracket
+		case c == '(' || c == '[' || c == '<':
+			depth++
+		case c == ')' || c == ']' || c == '>':
+			if depth > 0 {
+				depth--
+			}
+		case c == '\n':
+			depth = 0
+			if start >= 0 {
+				usages = append(usages, help[start:i])
+				start = -1
+			}
+		case c == '/' && depth == 0 && i+1 < len(help) && isLetter(help[i+1]):
+			if start >= 0 {
+				usages = append(usages, help[start:i])
+			}
+			start = i + 1
+		}
+	}
+	if start >= 0 {
+		usages = append(usages, help[start:])
+	}
+
+	return usages
+}
+
+// addUsage adds a single usage string, minus its slash, to the tree under root.
+func addUsage(root *Node, usage string) {
+	tokens := tokenize(usage)
+	if len(tokens) == 0 {
+		return
+	}
+
+	cmd := root.child(&Node{Name: tokens[0]})
+	if len(tokens) == 1 {
+		cmd.Executable = true
+		return
+	}
+	if tokens[1] == "->" {
+		if len(tokens) > 2 {
+			cmd.Redirect = tokens[2]
+		}
+		return
+	}
+
+	for _, n := range addSequence([]*Node{cmd}, tokens[1:]) {
+		n.Executable = true
+	}
+}
+
+// addSequence adds the words described by tokens after each of the nodes in from, returning the nodes at the end.
+func addSequence(from []*Node, tokens []string) []*Node {
+	for _, tok := range tokens {
+		var next []*Node
+
+		switch {
+		case strings.HasPrefix(tok, "[") && strings.HasSuffix(tok, "]"):
+			// Optional, so the command can end before it.
+			for _, n := range from {
+				n.Executable = true
+			}
+			next = addSequence(from, tokenize(tok[1:len(tok)-1]))
+
+		case strings.HasPrefix(tok, "(") && strings.HasSuffix(tok, ")"):
+			// A fork. Brigadier doesn't describe anything past one, so each branch may go on further.
+			for _, alt := range splitAlternatives(tok[1 : len(tok)-1]) {
+				for _, n := range addSequence(from, tokenize(alt)) {
+					n.Open = true
+					next = append(next, n)
+				}
+			}
+
+		default:
+			proto := &Node{Name: tok}
+			if strings.HasPrefix(tok, "<") && strings.HasSuffix(tok, ">") {
+				proto = &Node{Name: tok[1 : len(tok)-1], Argument: true}
+			}
+			for _, n := range from {
+				next = append(next, n.child(&Node{Name: proto.Name, Argument: proto.Argument}))
+			}
+		}
+
+		from = next
+	}
+
+	return from
+}
+
+// tokenize splits s at spaces that aren't inside brackets.
+func tokenize(s string) []string {
+	var tokens []string
+	depth, start := 0, 0
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(', '[', '<':
+			depth++
+		case ')', ']', '>':
+			if depth > 0 && !(s[i] == '>' && i > 0 && s[i-1] == '-') {
+				depth--
+			}
+		case ' ':
+			if depth == 0 {
+				if i > start {
+					tokens = append(tokens, s[start:i])
+				}
+				start = i + 1
+			}
+		}
+	}
+	if start < len(s) {
+		tokens = append(tokens, s[start:])
+	}
+
+	return tokens
+}
+
+// splitAlternatives splits the inside of a (a|b|c) fork at bars that aren't inside further brackets.
+func splitAlternatives(s string) []string {
+	var alts []string
+	depth, start := 0, 0
+
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '(', '[', '<':
+			depth++
+		case ')', ']', '>':
+			if depth > 0 {
+				depth--
+			}
+		case '|':
+			if depth == 0 {
+				alts = append(alts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+
+	return append(alts, s[start:])
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
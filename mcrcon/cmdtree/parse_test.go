@@ -0,0 +1,175 @@
+package cmdtree
+
+import (
+	"errors"
+	rcmd "github.com/joshproehl/minecontrol/mcrcon/commands"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// vanillaHelp is "help" from a 1.16 server over RCON (trimmed to fewer commands), where the usages come run together.
+const vanillaHelp = "/advancement (grant|revoke)/attribute <target> <attribute> (base|get|modifier)/execute (align|anchored|as|at|facing|if|in|positioned|rotated|run|store|unless)/bossbar (add|get|list|remove|set)/clear [<targets>]/clone <begin> <end> <destination> [filtered|masked|replace]/data (get|merge|modify|remove)/defaultgamemode (adventure|creative|spectator|survival)/difficulty [easy|hard|normal|peaceful]/effect (clear|give)/me <action>/experience (add|query|set)/xp -> experience/gamemode (adventure|creative|spectator|survival)/gamerule (announceAdvancements|doDaylightCycle|keepInventory)/give <targets> <item> [<count>]/help [<command>]/kick <targets> [<reason>]/kill [<targets>]/list [uuids]/msg <targets> <message>/tell -> msg/w -> msg/say <message>/seed/time (add|query|set)/weather (clear|rain|thunder)/whitelist (add|list|off|on|reload|remove)/save-all [flush]/save-off/save-on/stop"
+
+// vanillaConsoleHelp is the same from the server console, one usage per line.
+const vanillaConsoleHelp = `/list [uuids]
+/tell -> msg
+/msg <targets> <message>
+/whitelist (add|list|off|on|reload|remove)
+/stop
+`
+
+// bukkitHelp is the first page of "help" from a Paper server, with its formatting codes.
+const bukkitHelp = "§e--------- §fHelp: Index (1/2) §e--------------------\n" +
+	"§7Use /help [n] to get page n of help.\n" +
+	"§6Aliases: §fLists command aliases\n" +
+	"§6Bukkit: §fAll commands for Bukkit\n" +
+	"§6Essentials: §fAll commands for Essentials\n" +
+	"§6/advancement: §fA Mojang provided command.\n" +
+	"§6/ban: §fA Mojang provided command.\n" +
+	"§6/balance: §fStates the current balance of a player.\n" +
+	"§6/home: §fTeleport to your home.\n" +
+	"§6/list: §fA Mojang provided command."
+
+func TestParseVanillaRunTogether(t *testing.T) {
+	tree := Parse(vanillaHelp)
+
+	commands := tree.Commands()
+	for _, want := range []string{"advancement", "attribute", "clone", "list", "msg", "tell", "w", "xp", "save-all", "save-off", "stop"} {
+		if !contains(commands, want) {
+			t.Errorf("Commands() is missing %q: %q", want, commands)
+		}
+	}
+	if len(commands) != strings.Count(vanillaHelp, "/") {
+		t.Errorf("Commands() found %d commands, want %d: %q", len(commands), strings.Count(vanillaHelp, "/"), commands)
+	}
+
+	// The arrow in "/xp -> experience" mustn't be taken as closing a bracket, or everything after would be swallowed.
+	for _, n := range tree.Root.Children {
+		switch n.Name {
+		case "xp":
+			if n.Redirect != "experience" {
+				t.Errorf("xp redirects to %q, want experience", n.Redirect)
+			}
+		case "tell", "w":
+			if n.Redirect != "msg" {
+				t.Errorf("%s redirects to %q, want msg", n.Name, n.Redirect)
+			}
+		}
+	}
+}
+
+func TestParseVanillaLines(t *testing.T) {
+	tree := Parse(vanillaConsoleHelp)
+
+	want := []string{"list", "msg", "stop", "tell", "whitelist"}
+	if got := tree.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Commands() = %q, want %q", got, want)
+	}
+}
+
+func TestParseBukkit(t *testing.T) {
+	tree := Parse(bukkitHelp)
+
+	want := []string{"advancement", "balance", "ban", "home", "list"}
+	if got := tree.Commands(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Commands() = %q, want %q", got, want)
+	}
+
+	// Only the names are known, so anything after them goes.
+	for _, command := range []string{"ban Notch griefing", "home", "balance Notch", "/list"} {
+		if err := tree.Validate(command); err != nil {
+			t.Errorf("Validate(%q): %v", command, err)
+		}
+	}
+	if err := tree.Validate("bna Notch"); !errors.Is(err, rcmd.ErrUnknownCommand) {
+		t.Errorf("Validate(\"bna Notch\") = %v, want an unknown command", err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tree := Parse(vanillaHelp)
+
+	for _, command := range []string{
+		"list",
+		"list uuids",
+		"/list",
+		"minecraft:list",
+		"xp add @p 5 levels",
+		"tell Notch hello there",
+		"w Notch hi",
+		"whitelist add Notch",
+		"save-all flush",
+		"save-all",
+		"execute as @a at @s run say hi",
+		"gamemode creative Notch",
+		"difficulty",
+		"give @p minecraft:diamond 64",
+	} {
+		if err := tree.Validate(command); err != nil {
+			t.Errorf("Validate(%q): %v", command, err)
+		}
+	}
+
+	tests := []struct {
+		command     string
+		word, after string
+		suggestions []string
+	}{
+		{"lst", "lst", "", []string{"list"}},
+		{"whitelist ad Notch", "ad", "whitelist", []string{"add"}},
+		{"save-all flsh", "flsh", "save-all", []string{"flush"}},
+		{"weather snow", "snow", "weather", nil},
+	}
+	for _, tt := range tests {
+		err := tree.Validate(tt.command)
+
+		var ue *UnknownError
+		if !errors.As(err, &ue) {
+			t.Errorf("Validate(%q) = %v, want an *UnknownError", tt.command, err)
+			continue
+		}
+		if !errors.Is(err, rcmd.ErrUnknownCommand) {
+			t.Errorf("Validate(%q) doesn't wrap ErrUnknownCommand", tt.command)
+		}
+		if ue.Word != tt.word || ue.After != tt.after || !reflect.DeepEqual(ue.Suggestions, tt.suggestions) {
+			t.Errorf("Validate(%q) = %+v, want word %q after %q suggesting %q", tt.command, ue, tt.word, tt.after, tt.suggestions)
+		}
+	}
+}
+
+func TestNilTreeAcceptsEverything(t *testing.T) {
+	var tree *Tree
+	if err := tree.Validate("anything at all"); err != nil {
+		t.Errorf("nil tree rejected a command: %v", err)
+	}
+	if got := tree.Commands(); got != nil {
+		t.Errorf("nil tree has commands %q", got)
+	}
+}
+
+func TestStripUnchecked(t *testing.T) {
+	tests := []struct {
+		command   string
+		want      string
+		unchecked bool
+	}{
+		{"list", "list", false},
+		{"!list", "list", true},
+		{" ! myplugin reload", "myplugin reload", true},
+	}
+	for _, tt := range tests {
+		if got, unchecked := StripUnchecked(tt.command); got != tt.want || unchecked != tt.unchecked {
+			t.Errorf("StripUnchecked(%q) = %q, %v, want %q, %v", tt.command, got, unchecked, tt.want, tt.unchecked)
+		}
+	}
+}
+
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
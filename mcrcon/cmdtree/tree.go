@@ -0,0 +1,271 @@
+// cmdtree works out which commands a server has, and what arguments they take, from the usage strings it prints for
+// "help". Those are Brigadier's "smart usage" on modern servers, like
+//
+//	/whitelist (add|list|off|on|reload|remove)
+//	/gamemode <gamemode> [<target>]
+//
+// which only describe the first fork in each command, so the tree is exact about command names and the literal words
+// near the start of a command, and deliberately vague past that. That's enough to complete the REPL and to catch typos
+// before they're sent, without ever rejecting a command the server would have accepted.
+package cmdtree
+
+import (
+	"fmt"
+	rcmd "github.com/joshproehl/minecontrol/mcrcon/commands"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Unchecked starts a command that should be sent without checking it against the tree, for when the tree is behind the
+// server, e.g. after a plugin was added. See StripUnchecked.
+const Unchecked = "!"
+
+// Node is a command, or a word of one.
+type Node struct {
+	Name       string  `json:"name"`                 // The literal word, or the argument's name
+	Argument   bool    `json:"argument,omitempty"`   // A value like <targets>, rather than a literal word
+	Executable bool    `json:"executable,omitempty"` // The command can stop here, the rest being optional
+	Open       bool    `json:"open,omitempty"`       // There's more to the command than the usage showed
+	Redirect   string  `json:"redirect,omitempty"`   // An alias, e.g. tell redirects to msg
+	Children   []*Node `json:"children,omitempty"`
+}
+
+// Tree is everything known about a server's commands. The methods are safe to call on a nil *Tree, which knows nothing
+// and so accepts every command, so callers can carry on without one if discovery fails.
+type Tree struct {
+	Server     string    `json:"server"` // host:port of the server it came from
+	Version    string    `json:"version"`
+	Discovered time.Time `json:"discovered"`
+	Root       *Node     `json:"root"`
+}
+
+// UnknownError is returned by Validate for a word that doesn't fit anywhere. It wraps commands.ErrUnknownCommand.
+type UnknownError struct {
+	Command     string
+	Word        string   // The word that didn't fit
+	After       string   // What came before it, empty if it's the command name that's unknown
+	Suggestions []string // The closest words that would have fitted
+}
+
+func (e *UnknownError) Error() string {
+	msg := fmt.Sprintf("Unknown command %q.", e.Word)
+	if e.After != "" {
+		msg = fmt.Sprintf("Unknown argument %q after %q.", e.Word, e.After)
+	}
+
+	if len(e.Suggestions) > 0 {
+		quoted := make([]string, len(e.Suggestions))
+		for i, s := range e.Suggestions {
+			quoted[i] = fmt.Sprintf("%q", s)
+		}
+		msg += " Did you mean " + strings.Join(quoted, " or ") + "?"
+	}
+
+	return msg + fmt.Sprintf(" (Start the command with %s to send it anyway.)", Unchecked)
+}
+
+func (e *UnknownError) Unwrap() error {
+	return rcmd.ErrUnknownCommand
+}
+
+// StripUnchecked takes the Unchecked mark off the start of command, reporting whether it was there.
+func StripUnchecked(command string) (string, bool) {
+	trimmed := strings.TrimSpace(command)
+	if !strings.HasPrefix(trimmed, Unchecked) {
+		return command, false
+	}
+	return strings.TrimSpace(strings.TrimPrefix(trimmed, Unchecked)), true
+}
+
+// Commands returns the names of all the server's commands.
+func (t *Tree) Commands() []string {
+	if t == nil || t.Root == nil {
+		return nil
+	}
+	return t.Root.literals()
+}
+
+// Validate checks command's name and any literal words the tree knows about. It stops checking at the first argument,
+// since it can't tell how many words each one takes, so passing Validate doesn't mean the server will accept the command.
+func (t *Tree) Validate(command string) error {
+	words := commandWords(command)
+	if t == nil || t.Root == nil || len(words) == 0 {
+		return nil
+	}
+
+	node := t.Root
+	for i, w := range words {
+		node = t.follow(node)
+		if node == nil || node.Open || len(node.Children) == 0 || node.hasArgument() {
+			return nil
+		}
+
+		child := node.literal(w)
+		if child == nil {
+			return &UnknownError{
+				Command:     command,
+				Word:        w,
+				After:       strings.Join(words[:i], " "),
+				Suggestions: Suggest(w, node.literals()),
+			}
+		}
+		node = child
+	}
+
+	return nil
+}
+
+// Complete returns the literal words that can come after words, which are the complete words typed so far. An empty
+// result means the tree doesn't know, not that nothing can follow.
+func (t *Tree) Complete(words []string) []string {
+	if t == nil || t.Root == nil {
+		return nil
+	}
+
+	node := t.Root
+	for i, w := range words {
+		if i == 0 {
+			w = strings.TrimPrefix(strings.TrimPrefix(w, "/"), "minecraft:")
+		}
+		node = t.follow(node)
+		if node == nil || node.Open {
+			return nil
+		}
+		if node = node.literal(w); node == nil {
+			return nil
+		}
+	}
+
+	if node = t.follow(node); node == nil || node.Open {
+		return nil
+	}
+	return node.literals()
+}
+
+// follow resolves aliases to the command they stand for.
+func (t *Tree) follow(n *Node) *Node {
+	for hops := 0; n != nil && n.Redirect != "" && hops < 8; hops++ {
+		n = t.Root.literal(n.Redirect)
+	}
+	return n
+}
+
+// commandWords splits a command into words, without any leading slash or the minecraft: namespace on its name.
+func commandWords(command string) []string {
+	words := strings.Fields(strings.TrimPrefix(strings.TrimSpace(command), "/"))
+	if len(words) > 0 {
+		words[0] = strings.TrimPrefix(words[0], "minecraft:")
+	}
+	return words
+}
+
+func (n *Node) literal(word string) *Node {
+	for _, c := range n.Children {
+		if !c.Argument && strings.EqualFold(c.Name, word) {
+			return c
+		}
+	}
+	return nil
+}
+
+func (n *Node) literals() []string {
+	var names []string
+	for _, c := range n.Children {
+		if !c.Argument {
+			names = append(names, c.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (n *Node) hasArgument() bool {
+	for _, c := range n.Children {
+		if c.Argument {
+			return true
+		}
+	}
+	return false
+}
+
+// child returns n's child matching proto, adding proto if there isn't one, so usages that share a start share nodes.
+func (n *Node) child(proto *Node) *Node {
+	for _, c := range n.Children {
+		if c.Name == proto.Name && c.Argument == proto.Argument {
+			return c
+		}
+	}
+	n.Children = append(n.Children, proto)
+	return proto
+}
+
+// Suggest returns the candidates closest to word, for "did you mean" messages: those within a couple of typos of it, or
+// that it's the start of, nearest first.
+func Suggest(word string, candidates []string) []string {
+	type match struct {
+		name string
+		dist int
+	}
+
+	word = strings.ToLower(word)
+	limit := 2
+	if len(word) <= 3 {
+		limit = 1
+	}
+
+	var matches []match
+	for _, c := range candidates {
+		lc := strings.ToLower(c)
+		d := levenshtein(word, lc)
+		if d <= limit || (len(word) >= 3 && strings.HasPrefix(lc, word)) {
+			matches = append(matches, match{c, d})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].dist != matches[j].dist {
+			return matches[i].dist < matches[j].dist
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	var out []string
+	for i := 0; i < len(matches) && i < 3; i++ {
+		out = append(out, matches[i].name)
+	}
+	return out
+}
+
+// levenshtein is the edit distance between a and b.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+
+	return prev[len(b)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
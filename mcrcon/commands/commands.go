@@ -0,0 +1,128 @@
+// commands wraps the vanilla server commands in typed methods, so callers don't have to build command strings or pick
+// through the text that comes back. Failures the server reports in its response, which RCON delivers like any other
+// output, come back as a *CommandError wrapping one of the sentinel errors below.
+//
+// The cobra commands package shares this name, so import it under another, e.g.
+//
+//	rcmd "github.com/joshproehl/minecontrol/mcrcon/commands"
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/joshproehl/minecontrol/formatting"
+	"github.com/joshproehl/minecontrol/mcrcon"
+	"regexp"
+	"strings"
+)
+
+var (
+	// ErrUnknownCommand means the server doesn't have the command, or couldn't make sense of how it was written.
+	ErrUnknownCommand = errors.New("Unknown command")
+
+	// ErrInvalidArgument means the command exists but one of its arguments was rejected.
+	ErrInvalidArgument = errors.New("Invalid argument")
+
+	// ErrPlayerNotFound means the command needed a player who doesn't exist, or isn't online.
+	ErrPlayerNotFound = errors.New("Player not found")
+
+	// ErrNothingChanged means the command was valid but had nothing to do, like opping someone who's already an op.
+	ErrNothingChanged = errors.New("Nothing changed")
+
+	// ErrUnexpectedResponse means the command seemed to run, but the response wasn't in a format we recognise.
+	ErrUnexpectedResponse = errors.New("Unexpected response")
+)
+
+// CommandError is a failure reported by the server in response to a command. Use errors.Is with the sentinel errors to
+// tell what kind of failure it was.
+type CommandError struct {
+	Command  string
+	Response string // The server's response, with formatting codes removed
+	Err      error
+}
+
+func (e *CommandError) Error() string {
+	return fmt.Sprintf("%s: %q: %s", e.Err, e.Command, e.Response)
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// failures maps the responses the server uses to report a failure onto our errors. They're only ever checked against
+// the start of a line, since responses to successful commands can contain player-supplied text like ban reasons, and
+// each is spelled out up to the end of a word so that text which merely starts the same way, like "Expectedly", doesn't
+// count.
+var failures = []struct {
+	re  *regexp.Regexp
+	err error
+}{
+	{regexp.MustCompile(`^(?:Unknown or incomplete command|Unknown command)\b`), ErrUnknownCommand},
+	{regexp.MustCompile(`^(?:That player does not exist|No player was found|Player not found|Can't find player|Could not (?:find|ban|pardon))\b`), ErrPlayerNotFound},
+	{regexp.MustCompile(`^(?:Nothing changed|Player is already whitelisted|Player is not whitelisted|Saving is already turned (?:on|off)|The difficulty did not change)\b`), ErrNothingChanged},
+	{regexp.MustCompile(`^(?:Incorrect argument for command|(?:Integer|Long|Float|Double) must not be (?:less|more) than|No game rule called|Unknown (?:game ?rule|difficulty))\b`), ErrInvalidArgument},
+	{regexp.MustCompile(`^Invalid (?:integer|long|float|double|boolean|UUID|name or UUID|array type|escape sequence|ID|angle|unit|tick count)\b`), ErrInvalidArgument},
+	{regexp.MustCompile(`^Expected (?:(?:integer|long|float|double|boolean|bool|value|key|quote|literal|whitespace|end of options)\b|')`), ErrInvalidArgument},
+}
+
+// Client runs typed commands on a server through anything that can send them, usually an *mcrcon.MCRCONClient or an
+// *mcrcon.Pool.
+type Client struct {
+	c mcrcon.Commander
+}
+
+// New returns a Client which sends its commands through c.
+func New(c mcrcon.Commander) *Client {
+	return &Client{c: c}
+}
+
+// Run sends a raw command and returns its response, with formatting codes removed. If the response is one the server
+// uses to report a failure, it's returned as a *CommandError as well.
+func (cl *Client) Run(ctx context.Context, command string) (string, error) {
+	response, err := cl.c.SendCommandContext(ctx, command)
+	if err != nil {
+		return "", err
+	}
+
+	response = strings.TrimSpace(formatting.Strip(response))
+	return response, Check(command, response)
+}
+
+// Check looks for a failure reported in response to command, returning a *CommandError if there is one and nil if not.
+// Responses it doesn't recognise are assumed to mean success.
+func Check(command, response string) error {
+	response = formatting.Strip(response)
+
+	for _, line := range strings.Split(response, "\n") {
+		line = strings.TrimSpace(line)
+		for _, f := range failures {
+			if f.re.MatchString(line) {
+				return &CommandError{Command: command, Response: strings.TrimSpace(response), Err: f.err}
+			}
+		}
+	}
+
+	return nil
+}
+
+// unexpected wraps a response we couldn't parse.
+func unexpected(command, response string) error {
+	return &CommandError{Command: command, Response: response, Err: ErrUnexpectedResponse}
+}
+
+// playerArg checks name is safe to put in a command.
+func playerArg(name string) error {
+	if !mcrcon.ValidPlayerName(name) {
+		return fmt.Errorf("%w: invalid player name %q", ErrInvalidArgument, name)
+	}
+	return nil
+}
+
+// reasonArg checks a free-text reason can't be used to smuggle a second command or line into the one it's part of.
+func reasonArg(reason string) error {
+	if strings.ContainsAny(reason, "\r\n\x00") {
+		return fmt.Errorf("%w: reason contains a line break", ErrInvalidArgument)
+	}
+	return nil
+}
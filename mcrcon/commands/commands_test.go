@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		response string
+		want     error
+	}{
+		// Failures, as vanilla and Bukkit word them.
+		{"Unknown or incomplete command, see below for error\nfoo<--[HERE]", ErrUnknownCommand},
+		{"Unknown command. Type \"/help\" for help.", ErrUnknownCommand},
+		{"§cUnknown or incomplete command, see below for error", ErrUnknownCommand},
+		{"That player does not exist", ErrPlayerNotFound},
+		{"No player was found", ErrPlayerNotFound},
+		{"Could not ban Notch", ErrPlayerNotFound},
+		{"Player is already whitelisted", ErrNothingChanged},
+		{"Nothing changed. The player is already an operator", ErrNothingChanged},
+		{"Saving is already turned off", ErrNothingChanged},
+		{"The difficulty did not change; it is already set to normal", ErrNothingChanged},
+		{"Incorrect argument for command\n...me tp @p <--[HERE]", ErrInvalidArgument},
+		{"Invalid integer 'x'\n...ime set x<--[HERE]", ErrInvalidArgument},
+		{"Invalid name or UUID", ErrInvalidArgument},
+		{"Invalid boolean, expected 'true' or 'false' but found 'yes'", ErrInvalidArgument},
+		{"Expected integer\n...time set <--[HERE]", ErrInvalidArgument},
+		{"Expected whitespace to end one argument, but found trailing data", ErrInvalidArgument},
+		{"Expected ']'", ErrInvalidArgument},
+		{"Integer must not be less than 0, found -1", ErrInvalidArgument},
+		{"Unknown difficulty 'impossible'", ErrInvalidArgument},
+		{"Unknown game rule: keepStuff", ErrInvalidArgument},
+
+		// Successes that only look like failures.
+		{"", nil},
+		{"Made Notch a server operator", nil},
+		{"Banned Notch: Unknown command spam", nil},
+		{"Expectedly, nothing happened", nil},
+		{"Invalidated 3 sessions", nil},
+		{"Invalid", nil},
+		{"Expected", nil},
+		{"Unknown commander", nil},
+		{"Storage minecraft:test has the following contents: \"Invalidate the cache\"", nil},
+		{"Notch has the following entity data: \"Invalid\"", nil},
+		{"There are 1 ban(s):Notch was banned by Server: No player was found", nil},
+	}
+
+	for _, tt := range tests {
+		err := Check("cmd", tt.response)
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("Check(%q) = %v, want nil", tt.response, err)
+			}
+			continue
+		}
+
+		var ce *CommandError
+		if !errors.As(err, &ce) || !errors.Is(err, tt.want) {
+			t.Errorf("Check(%q) = %v, want a CommandError wrapping %v", tt.response, err, tt.want)
+			continue
+		}
+		if ce.Command != "cmd" {
+			t.Errorf("Check(%q) has command %q", tt.response, ce.Command)
+		}
+	}
+}
+
+// recorder is a Commander that remembers what it was asked to run and answers with a fixed response.
+type recorder struct {
+	sent     []string
+	response string
+}
+
+func (r *recorder) SendCommandContext(ctx context.Context, command string) (string, error) {
+	r.sent = append(r.sent, command)
+	return r.response, nil
+}
+
+func TestArgumentValidation(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name string
+		call func(cl *Client) error
+	}{
+		{"empty name", func(cl *Client) error { return cl.Op(ctx, "") }},
+		{"name too long", func(cl *Client) error { return cl.Op(ctx, "abcdefghijklmnopq") }},
+		{"name with a space", func(cl *Client) error { return cl.Ban(ctx, "Notch stop", "") }},
+		{"selector", func(cl *Client) error { return cl.Kick(ctx, "@a", "") }},
+		{"name with a newline", func(cl *Client) error { return cl.WhitelistAdd(ctx, "Notch\nstop") }},
+		{"reason with a newline", func(cl *Client) error { return cl.Ban(ctx, "Notch", "griefing\nstop") }},
+		{"reason with a carriage return", func(cl *Client) error { return cl.Kick(ctx, "Notch", "bye\rstop") }},
+		{"reason with a null", func(cl *Client) error { return cl.Kick(ctx, "Notch", "bye\x00") }},
+		{"game rule with a space", func(cl *Client) error { return cl.GameruleSet(ctx, "keepInventory true", "x") }},
+		{"game rule starting with a digit", func(cl *Client) error { _, err := cl.GameruleGet(ctx, "1rule"); return err }},
+		{"game rule value with a space", func(cl *Client) error { return cl.GameruleSet(ctx, "keepInventory", "true\nstop") }},
+		{"empty game rule value", func(cl *Client) error { return cl.GameruleSet(ctx, "keepInventory", "") }},
+		{"negative time", func(cl *Client) error { return cl.TimeSet(ctx, -1) }},
+		{"unknown weather", func(cl *Client) error { return cl.Weather(ctx, "snow") }},
+		{"unknown difficulty", func(cl *Client) error { return cl.SetDifficulty(ctx, "impossible") }},
+	}
+
+	for _, tt := range tests {
+		r := &recorder{}
+		err := tt.call(New(r))
+		if !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("%s: got %v, want ErrInvalidArgument", tt.name, err)
+		}
+		if len(r.sent) != 0 {
+			t.Errorf("%s: sent %q anyway", tt.name, r.sent)
+		}
+	}
+}
+
+func TestPlayerCommands(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		call func(cl *Client) error
+		want string
+	}{
+		{func(cl *Client) error { return cl.Ban(ctx, "Notch", "") }, "ban Notch"},
+		{func(cl *Client) error { return cl.Ban(ctx, "Notch", "griefing the spawn") }, "ban Notch griefing the spawn"},
+		{func(cl *Client) error { return cl.Kick(ctx, "jeb_", "back soon") }, "kick jeb_ back soon"},
+		{func(cl *Client) error { return cl.WhitelistRemove(ctx, "Dinnerbone") }, "whitelist remove Dinnerbone"},
+		{func(cl *Client) error { return cl.GameruleSet(ctx, "minecraft:keepInventory", "true") }, "gamerule minecraft:keepInventory true"},
+	}
+
+	for _, tt := range tests {
+		r := &recorder{}
+		if err := tt.call(New(r)); err != nil {
+			t.Errorf("%s: %v", tt.want, err)
+		}
+		if len(r.sent) != 1 || r.sent[0] != tt.want {
+			t.Errorf("sent %q, want %q", r.sent, tt.want)
+		}
+	}
+}
+
+func TestRunReportsFailures(t *testing.T) {
+	r := &recorder{response: "§cThat player does not exist"}
+
+	err := New(r).Pardon(context.Background(), "Notch")
+	if !errors.Is(err, ErrPlayerNotFound) {
+		t.Fatalf("got %v, want ErrPlayerNotFound", err)
+	}
+
+	var ce *CommandError
+	if errors.As(err, &ce) && ce.Response != "That player does not exist" {
+		t.Errorf("response %q still has its formatting", ce.Response)
+	}
+}
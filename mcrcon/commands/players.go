@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"context"
+	"github.com/joshproehl/minecontrol/mcrcon"
+)
+
+// WhitelistAdd adds a player to the whitelist.
+func (cl *Client) WhitelistAdd(ctx context.Context, name string) error {
+	return cl.playerCommand(ctx, "whitelist add", name, "")
+}
+
+// WhitelistRemove takes a player off the whitelist.
+func (cl *Client) WhitelistRemove(ctx context.Context, name string) error {
+	return cl.playerCommand(ctx, "whitelist remove", name, "")
+}
+
+// WhitelistList returns the names of the whitelisted players.
+func (cl *Client) WhitelistList(ctx context.Context) ([]string, error) {
+	response, err := cl.Run(ctx, "whitelist list")
+	if err != nil {
+		return nil, err
+	}
+	return mcrcon.ParseWhitelist(response), nil
+}
+
+// Ban bans a player, kicking them if they're online. reason may be empty.
+func (cl *Client) Ban(ctx context.Context, name, reason string) error {
+	return cl.playerCommand(ctx, "ban", name, reason)
+}
+
+// Pardon lifts a player's ban.
+func (cl *Client) Pardon(ctx context.Context, name string) error {
+	return cl.playerCommand(ctx, "pardon", name, "")
+}
+
+// BanList returns the names of the banned players.
+func (cl *Client) BanList(ctx context.Context) ([]string, error) {
+	response, err := cl.Run(ctx, "banlist players")
+	if err != nil {
+		return nil, err
+	}
+	return mcrcon.ParseBanList(response), nil
+}
+
+// Op makes a player a server operator.
+func (cl *Client) Op(ctx context.Context, name string) error {
+	return cl.playerCommand(ctx, "op", name, "")
+}
+
+// Deop takes away a player's operator status.
+func (cl *Client) Deop(ctx context.Context, name string) error {
+	return cl.playerCommand(ctx, "deop", name, "")
+}
+
+// Kick disconnects a player. reason may be empty.
+func (cl *Client) Kick(ctx context.Context, name, reason string) error {
+	return cl.playerCommand(ctx, "kick", name, reason)
+}
+
+// playerCommand runs a command that takes a player name and optionally a reason, and only reports success or failure.
+func (cl *Client) playerCommand(ctx context.Context, command, name, reason string) error {
+	if err := playerArg(name); err != nil {
+		return err
+	}
+	if err := reasonArg(reason); err != nil {
+		return err
+	}
+
+	command += " " + name
+	if reason != "" {
+		command += " " + reason
+	}
+
+	_, err := cl.Run(ctx, command)
+	return err
+}
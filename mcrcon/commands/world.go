@@ -0,0 +1,203 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// TimeCounter is one of the clocks "time query" can read.
+type TimeCounter string
+
+const (
+	QueryDaytime  TimeCounter = "daytime"  // Ticks since dawn of the current day
+	QueryGametime TimeCounter = "gametime" // Ticks since the world was created
+	QueryDay      TimeCounter = "day"      // Days since the world was created
+)
+
+// Times of day in ticks, as used by the names "time set" accepts.
+const (
+	TimeDay      = 1000
+	TimeNoon     = 6000
+	TimeNight    = 13000
+	TimeMidnight = 18000
+)
+
+// Weather is a kind of weather.
+type Weather string
+
+const (
+	WeatherClear   Weather = "clear"
+	WeatherRain    Weather = "rain"
+	WeatherThunder Weather = "thunder"
+)
+
+// Difficulty is a world difficulty.
+type Difficulty string
+
+const (
+	Peaceful Difficulty = "peaceful"
+	Easy     Difficulty = "easy"
+	Normal   Difficulty = "normal"
+	Hard     Difficulty = "hard"
+)
+
+var (
+	// "The time is 1234"
+	timeRe = regexp.MustCompile(`The time is (-?\d+)`)
+
+	// "Gamerule keepInventory is currently set to: false", or "keepInventory = false" before 1.13
+	gameruleRe = regexp.MustCompile(`(?:is currently set to:|=)\s*(\S+)\s*$`)
+
+	// Game rule names, which is also what keeps them safe to put into commands.
+	gameruleNameRe = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.:]*$`)
+
+	// "The difficulty is Normal"
+	difficultyRe = regexp.MustCompile(`The difficulty is (\w+)`)
+
+	// "Seed: [-4172144997902289642]", or without the brackets before 1.13
+	seedRe = regexp.MustCompile(`Seed: \[?(-?\d+)\]?`)
+)
+
+// TimeQuery reads one of the world's clocks.
+func (cl *Client) TimeQuery(ctx context.Context, counter TimeCounter) (int64, error) {
+	command := "time query " + string(counter)
+	response, err := cl.Run(ctx, command)
+	if err != nil {
+		return 0, err
+	}
+
+	m := timeRe.FindStringSubmatch(response)
+	if m == nil {
+		return 0, unexpected(command, response)
+	}
+	return strconv.ParseInt(m[1], 10, 64)
+}
+
+// TimeSet sets the time of day, in ticks since dawn. See TimeDay and friends for the usual values.
+func (cl *Client) TimeSet(ctx context.Context, ticks int) error {
+	if ticks < 0 {
+		return fmt.Errorf("%w: time can't be negative", ErrInvalidArgument)
+	}
+	_, err := cl.Run(ctx, fmt.Sprintf("time set %d", ticks))
+	return err
+}
+
+// Weather changes the weather.
+func (cl *Client) Weather(ctx context.Context, w Weather) error {
+	switch w {
+	case WeatherClear, WeatherRain, WeatherThunder:
+	default:
+		return fmt.Errorf("%w: unknown weather %q", ErrInvalidArgument, w)
+	}
+	_, err := cl.Run(ctx, "weather "+string(w))
+	return err
+}
+
+// GameruleGet returns the current value of a game rule, like "true" or "3".
+func (cl *Client) GameruleGet(ctx context.Context, rule string) (string, error) {
+	if !gameruleNameRe.MatchString(rule) {
+		return "", fmt.Errorf("%w: invalid game rule %q", ErrInvalidArgument, rule)
+	}
+
+	command := "gamerule " + rule
+	response, err := cl.Run(ctx, command)
+	if err != nil {
+		return "", err
+	}
+
+	m := gameruleRe.FindStringSubmatch(response)
+	if m == nil {
+		return "", unexpected(command, response)
+	}
+	return m[1], nil
+}
+
+// GameruleSet sets a game rule.
+func (cl *Client) GameruleSet(ctx context.Context, rule, value string) error {
+	if !gameruleNameRe.MatchString(rule) {
+		return fmt.Errorf("%w: invalid game rule %q", ErrInvalidArgument, rule)
+	}
+	if value == "" || strings.ContainsAny(value, " \t\r\n\x00") {
+		return fmt.Errorf("%w: invalid game rule value %q", ErrInvalidArgument, value)
+	}
+
+	_, err := cl.Run(ctx, "gamerule "+rule+" "+value)
+	return err
+}
+
+// Difficulty returns the world's difficulty.
+func (cl *Client) Difficulty(ctx context.Context) (Difficulty, error) {
+	response, err := cl.Run(ctx, "difficulty")
+	if err != nil {
+		return "", err
+	}
+
+	m := difficultyRe.FindStringSubmatch(response)
+	if m == nil {
+		return "", unexpected("difficulty", response)
+	}
+	return Difficulty(strings.ToLower(m[1])), nil
+}
+
+// SetDifficulty changes the world's difficulty. It returns an error wrapping ErrNothingChanged if it's already d.
+func (cl *Client) SetDifficulty(ctx context.Context, d Difficulty) error {
+	switch d {
+	case Peaceful, Easy, Normal, Hard:
+	default:
+		return fmt.Errorf("%w: unknown difficulty %q", ErrInvalidArgument, d)
+	}
+	_, err := cl.Run(ctx, "difficulty "+string(d))
+	return err
+}
+
+// Seed returns the world seed.
+func (cl *Client) Seed(ctx context.Context) (int64, error) {
+	response, err := cl.Run(ctx, "seed")
+	if err != nil {
+		return 0, err
+	}
+
+	m := seedRe.FindStringSubmatch(response)
+	if m == nil {
+		return 0, unexpected("seed", response)
+	}
+	return strconv.ParseInt(m[1], 10, 64)
+}
+
+// SaveAll saves the world. With flush it doesn't return until everything is written to disk, which can take a while on
+// a big world, so give it a generous deadline.
+func (cl *Client) SaveAll(ctx context.Context, flush bool) error {
+	command := "save-all"
+	if flush {
+		command += " flush"
+	}
+	_, err := cl.Run(ctx, command)
+	return err
+}
+
+// SaveOff stops the server saving the world automatically, e.g. while a backup is taken.
+func (cl *Client) SaveOff(ctx context.Context) error {
+	_, err := cl.Run(ctx, "save-off")
+	return err
+}
+
+// SaveOn turns automatic saving back on.
+func (cl *Client) SaveOn(ctx context.Context) error {
+	_, err := cl.Run(ctx, "save-on")
+	return err
+}
+
+// Stop shuts the server down. The server often drops the connection before it gets round to answering, so that counts as
+// success too.
+func (cl *Client) Stop(ctx context.Context) error {
+	_, err := cl.Run(ctx, "stop")
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil
+	}
+	return err
+}
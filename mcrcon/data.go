@@ -0,0 +1,43 @@
+package mcrcon
+
+import (
+	"context"
+	"fmt"
+	"github.com/joshproehl/minecontrol/snbt"
+	"strings"
+)
+
+// The text "data get" puts before the SNBT value it prints, for entities, blocks and storage respectively.
+var dataMarkers = []string{
+	" has the following entity data: ",
+	" has the following block data: ",
+	" has the following contents: ",
+}
+
+// DataGet runs "data get <target> [path]" and parses the SNBT it prints. target is everything between "data get" and
+// the path, like "entity Notch" or "block 10 64 -3".
+func DataGet(ctx context.Context, c Commander, target, path string) (snbt.Tag, error) {
+	cmd := "data get " + target
+	if path != "" {
+		cmd += " " + path
+	}
+
+	response, err := c.SendCommandContext(ctx, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseDataResponse(response)
+}
+
+// ParseDataResponse pulls the SNBT out of the response to a "data get" command. Anything else, like "No entity was
+// found", is the server telling us why there's no data, so it becomes the error.
+func ParseDataResponse(response string) (snbt.Tag, error) {
+	for _, marker := range dataMarkers {
+		if i := strings.Index(response, marker); i >= 0 {
+			return snbt.Parse(response[i+len(marker):])
+		}
+	}
+
+	return nil, fmt.Errorf("No data: %s", strings.TrimSpace(response))
+}
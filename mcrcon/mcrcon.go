@@ -4,50 +4,29 @@ package mcrcon
 
 import (
 	"bufio"
-	"encoding/binary"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"math"
 	"math/rand"
 	"net"
+	"strconv"
 	"sync"
 	"time"
 )
 
-/*
-MCRCONPacket defines the structure of a single packet either to send to or recieve from the RCON server.
+var (
+	// ErrAuthFailed is returned when the server rejects the RCON password, or answers a command with the -1 request ID
+	// it uses to mean we aren't logged in.
+	ErrAuthFailed = errors.New("RCON authentication failed")
 
-From wiki.vg/Rcon:
-  Packet Format
+	// ErrIDMismatch is returned when the server answers with a request ID that doesn't belong to the request we sent.
+	ErrIDMismatch = errors.New("RCON response has unexpected request ID")
 
-  Integers are little-endian, in contrast with the Beta protocol.
-  Responses are sent back with the same Request ID that you send.
-  In the event of an auth failure (i.e. your login is incorrect, or you're trying to send commands without first logging in), request ID will be set to -1
-
-  Field name  Field Type  Notes
-  Length      int         Length of remainder of packet
-  Request ID  int         Client-generated ID
-  Type        int         3 for login, 2 to run a command, 0 for a multi-packet response
-  Payload     byte[]      ASCII text
-  2-byte pad  byte, byte  Two null bytes
-
-  Packet Types
-
-  3: Login
-    Outgoing payload: password.
-    If the server returns a packet with the same request ID, auth was successful (note: packet type is 2, not 3). If you get an request ID of -1, auth failed (wrong password).
-  2: Command
-    Outgoing payload should be the command to run, e.g. time set 0
-  0: Command response
-    Incoming payload is the output of the command, though many commands return nothing, and there's no way of detecting unknown commands.
-    The output of the command may be split over multiple packets, each containing 4096 bytes (less for the last packet). Each packet contains part of the payload (and the two-byte padding). The last packet sent is the end of the output.
-
-  Maximum request length: 1460 (giving a max payload length of 1446)
-  Code exists in the notchian server to split large responses (>4096 bytes) into multiple smaller packets. However, the code that actually encodes each packet expects a max length of 1248, giving a max response payload length of 1234 bytes.
-*/
-type MCRCONPacket struct {
-	length, reqID, reqType int32
-	Payload                string
-	nullPad                [2]byte
-}
+	// ErrClosed is returned for commands sent on a client after Close, which a ReconnectPolicy won't undo.
+	ErrClosed = errors.New("RCON client closed")
+)
 
 // MCRCONClient represents a connection to a single RCON server.
 // MCRCONCLient is fully synchronized and may be shared between multiple goroutines safely.
@@ -56,6 +35,17 @@ type MCRCONClient struct {
 	Connected bool
 	conn      net.Conn
 	rw        *MCRCONReaderWriter
+
+	// Kept so the connection can be re-established, see SetReconnectPolicy.
+	addr      string
+	port      int
+	passwd    string
+	reconnect *ReconnectPolicy
+	onState   func(ConnState)
+
+	// closing is closed by Close, which interrupts a reconnect that's waiting out its backoff with the client locked.
+	closing   chan struct{}
+	closeOnce sync.Once
 }
 
 // MCRCONReaderWriter is a convenience container to hold both the input and output buffers and let them be passed around easily.
@@ -64,125 +54,315 @@ type MCRCONReaderWriter struct {
 	*bufio.Writer
 }
 
+// TimeoutError is returned when an RCON call doesn't finish before its context's deadline, or the context is cancelled
+// part way through. The connection is closed when this happens, since there's no telling what state the stream is in.
+type TimeoutError struct {
+	Op  string // The step that was interrupted: "dial", "auth", "write" or "read"
+	Err error  // The context's error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("RCON %s interrupted: %s", e.Op, e.Err)
+}
+
+// Timeout reports true, so TimeoutError satisfies the same interface as net package timeouts.
+func (e *TimeoutError) Timeout() bool {
+	return true
+}
+
+func (e *TimeoutError) Unwrap() error {
+	return e.Err
+}
+
 // NewClient takes an address and a password, and attempts to set up a TCP connection to an RCON server at the given address,
 // using the given password.
 func NewClient(addr string, port int, passwd string) (*MCRCONClient, error) {
-	nClient := MCRCONClient{}
+	return NewClientContext(context.Background(), addr, port, passwd)
+}
+
+// NewClientContext is NewClient with the dial and authentication bounded by ctx.
+func NewClientContext(ctx context.Context, addr string, port int, passwd string) (*MCRCONClient, error) {
+	nClient := MCRCONClient{addr: addr, port: port, passwd: passwd, closing: make(chan struct{})}
 	nClient.Connected = false
 
-	conn, err := net.Dial("tcp", fmt.Sprintf("%s:%d", addr, port))
-	if err != nil {
+	if err := nClient.connect(ctx); err != nil {
 		return nil, err
 	}
-	nClient.conn = conn
+
+	return &nClient, nil
+}
+
+// connect dials the server and logs in with the stored password. On success the client is marked Connected.
+// Must be called with the client locked, or before anyone else has it.
+func (client *MCRCONClient) connect(ctx context.Context) error {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(client.addr, strconv.Itoa(client.port)))
+	if err != nil {
+		if ctx.Err() != nil {
+			return &TimeoutError{Op: "dial", Err: ctx.Err()}
+		}
+		return err
+	}
+	client.conn = conn
 
 	w := bufio.NewWriter(conn)
 	r := bufio.NewReader(conn)
 	nRW := MCRCONReaderWriter{r, w}
-	nClient.rw = &nRW
+	client.rw = &nRW
 
-	// Make a pseudo-random session ID
-	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	stop := client.watchContext(ctx)
+	defer stop()
 
-	openPkt := nClient.buildPacket(rnd.Int(), 3, passwd)
-	nClient.writePacket(openPkt)
-	authPkt, err := nClient.readPacket()
+	openPkt := Packet{ID: newRequestID(), Type: PacketTypeLogin, Payload: client.passwd}
+	if err := client.writePacket(openPkt); err != nil {
+		conn.Close()
+		return client.contextError(ctx, "auth", err)
+	}
+	authPkt, err := client.readPacket()
 
 	if err != nil {
-		return nil, err
+		conn.Close()
+		return client.contextError(ctx, "auth", err)
 	}
 
-	// We're only connected if it returns a request type of 2.
-	if authPkt.reqType == 2 {
-		nClient.Connected = true
-	} else {
-		err := fmt.Errorf("Auth packet returned wrong type, not connected.")
-		return nil, err
+	// A request ID of -1 means the password was wrong.
+	if authPkt.ID == -1 {
+		conn.Close()
+		return ErrAuthFailed
+	}
+	if authPkt.ID != openPkt.ID {
+		conn.Close()
+		return fmt.Errorf("%w: sent %d, got %d", ErrIDMismatch, openPkt.ID, authPkt.ID)
 	}
 
-	return &nClient, nil
+	// We're only connected if it returns a request type of 2. (Not PacketTypeLogin, oddly.)
+	if authPkt.Type != PacketTypeCommand {
+		conn.Close()
+		return fmt.Errorf("Auth packet returned wrong type, not connected.")
+	}
+
+	client.Connected = true
+	client.setState(StateConnected)
+	return nil
 }
 
-// Close terminates RCON connection and sets the connected flag to false.
+// Close terminates RCON connection and sets the connected flag to false. The client can't be used again afterwards, even
+// with a ReconnectPolicy; commands return ErrClosed. A reconnect in progress is abandoned.
 func (client *MCRCONClient) Close() {
+	client.closeOnce.Do(func() {
+		close(client.closing)
+	})
+
 	client.m.Lock()
+	wasConnected := client.Connected
 	client.Connected = false
 	client.conn.Close()
+	if wasConnected {
+		client.setState(StateDisconnected)
+	}
 	client.m.Unlock()
 }
 
-// SendCommand takes a text string, executes the command on the connected client, and returns the text response
+// SendCommand takes a text string, executes the command on the connected client, and returns the text response.
 func (client *MCRCONClient) SendCommand(payload string) (string, error) {
-	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return client.SendCommandContext(context.Background(), payload)
+}
 
+// SendCommandContext is SendCommand with the round trip bounded by ctx. If ctx expires or is cancelled before the whole
+// response arrives the connection is closed, Connected becomes false, and a *TimeoutError is returned.
+//
+// If a ReconnectPolicy is set, a broken connection is re-established before giving up, see SetReconnectPolicy.
+func (client *MCRCONClient) SendCommandContext(ctx context.Context, payload string) (string, error) {
 	client.m.Lock()
+	defer client.m.Unlock()
+
+	if client.isClosed() {
+		return "", ErrClosed
+	}
+
 	if client.Connected == false {
-		return "", fmt.Errorf("Client not connected.")
+		if client.reconnect == nil {
+			return "", fmt.Errorf("Client not connected.")
+		}
+		if err := client.redial(ctx); err != nil {
+			return "", err
+		}
 	}
-	getUserPkt := client.buildPacket(rnd.Int(), 2, payload)
-	client.writePacket(getUserPkt)
-	rUserPkt, rUserErr := client.readPacket()
-	client.m.Unlock()
 
-	if rUserErr != nil {
-		return "", rUserErr
-	} else {
-		return rUserPkt.Payload, nil
+	response, sent, err := client.roundTrip(ctx, payload)
+	if err == nil || client.reconnect == nil || client.Connected {
+		return response, err
 	}
+
+	// Timeouts are the caller's deadline running out, not the connection breaking, so there's no time left to retry.
+	var te *TimeoutError
+	if errors.As(err, &te) {
+		return "", err
+	}
+
+	if rErr := client.redial(ctx); rErr != nil {
+		return "", rErr
+	}
+
+	if sent && !client.reconnect.replayable(payload) {
+		return "", fmt.Errorf("Connection lost after sending %q, reconnected but not replaying it. (Error was: %w)", payload, err)
+	}
+
+	response, _, err = client.roundTrip(ctx, payload)
+	return response, err
 }
 
-// Decode reads the RCON object's buffer and returns an MCRCONPacket representing binary data in the buffer.
-// NOTE: TODO: This currently doesn't support multi-packet responses, and would behave unpredictably if one is encountered.
-func (client *MCRCONClient) readPacket() (*MCRCONPacket, error) {
-	pkt := MCRCONPacket{}
+// roundTrip sends a single command and reads back the whole response. sent reports whether the command made it onto
+// the wire, in which case the server may have run it even if there was an error.
+//
+// The server may split a long response over several packets, and there's no way to tell from a fragment alone whether
+// it's the last one. So right after the command we send an empty response-type packet with its own request ID. The
+// server answers packets in order, so once the reply to that sentinel shows up we know the command output is complete.
+func (client *MCRCONClient) roundTrip(ctx context.Context, payload string) (response string, sent bool, err error) {
+	stop := client.watchContext(ctx)
+	defer stop()
+
+	cmdID := newRequestID()
+	endID := cmdID + 1
 
-	if err := binary.Read(client.rw, binary.LittleEndian, &pkt.length); err != nil {
-		return &pkt, err
+	if err := client.writePacket(Packet{ID: cmdID, Type: PacketTypeCommand, Payload: payload}); err != nil {
+		return "", false, client.fail(ctx, "write", err)
+	}
+	if err := client.writePacket(Packet{ID: endID, Type: PacketTypeResponse}); err != nil {
+		return "", true, client.fail(ctx, "write", err)
 	}
 
-	if err := binary.Read(client.rw, binary.LittleEndian, &pkt.reqID); err != nil {
-		return &pkt, err
+	var buf bytes.Buffer
+	for {
+		pkt, err := client.readPacket()
+		if err != nil {
+			return "", true, client.fail(ctx, "read", err)
+		}
+
+		if pkt.ID == endID {
+			break
+		}
+
+		// Anything other than a fragment of our response means we've lost track of the conversation, so the connection
+		// has to go.
+		if pkt.ID == -1 {
+			return "", true, client.fail(ctx, "read", ErrAuthFailed)
+		}
+		if pkt.ID != cmdID {
+			return "", true, client.fail(ctx, "read", fmt.Errorf("%w: sent %d, got %d", ErrIDMismatch, cmdID, pkt.ID))
+		}
+
+		buf.WriteString(pkt.Payload)
 	}
 
-	if err := binary.Read(client.rw, binary.LittleEndian, &pkt.reqType); err != nil {
-		return &pkt, err
+	return buf.String(), true, nil
+}
+
+// Ping checks the connection is still alive by sending an empty packet of a type the server doesn't run, and waiting
+// for the answer. Unlike SendCommand it never reconnects.
+func (client *MCRCONClient) Ping(ctx context.Context) error {
+	client.m.Lock()
+	defer client.m.Unlock()
+
+	if client.isClosed() {
+		return ErrClosed
 	}
+	if client.Connected == false {
+		return fmt.Errorf("Client not connected.")
+	}
+
+	stop := client.watchContext(ctx)
+	defer stop()
 
-	// Now we have the details, we'll need to load the length-10 bytes. This is because length includes the 2nd and 3rd fields (ints) and the last 2 null bytes.
-	bytePayload := make([]byte, (pkt.length - 10))
-	if err := binary.Read(client.rw, binary.LittleEndian, &bytePayload); err != nil {
-		return &pkt, err
+	id := newRequestID()
+	if err := client.writePacket(Packet{ID: id, Type: PacketTypeResponse}); err != nil {
+		return client.fail(ctx, "write", err)
 	}
-	pkt.Payload = string(bytePayload)
 
-	// Finally, read the last two bytes to make sure the pad is there. If these are not both NULL something went wrong.
-	if err := binary.Read(client.rw, binary.LittleEndian, &pkt.nullPad); err != nil {
-		return &pkt, err
+	pkt, err := client.readPacket()
+	if err != nil {
+		return client.fail(ctx, "read", err)
+	}
+	if pkt.ID != id {
+		return client.fail(ctx, "read", fmt.Errorf("%w: sent %d, got %d", ErrIDMismatch, id, pkt.ID))
 	}
-	// TODO: If the nullPad isn't actually NULLNULL we need to return an error.
 
-	return &pkt, nil
+	return nil
 }
 
-// Encode takes a MCRCONPacket and writes it into the client object's buffer in the correct binary format.
-// This function assumes that the Packet is complete and correct, and just writes the results out.
-func (client *MCRCONClient) writePacket(pkt *MCRCONPacket) error {
-	binary.Write(client.rw, binary.LittleEndian, pkt.length)
-	binary.Write(client.rw, binary.LittleEndian, pkt.reqID)
-	binary.Write(client.rw, binary.LittleEndian, pkt.reqType)
-	binary.Write(client.rw, binary.LittleEndian, []byte(pkt.Payload))
-	binary.Write(client.rw, binary.LittleEndian, pkt.nullPad)
+func (client *MCRCONClient) isClosed() bool {
+	select {
+	case <-client.closing:
+		return true
+	default:
+		return false
+	}
+}
+
+// watchContext applies ctx's deadline to the connection, and makes a cancelled ctx interrupt any read or write that's
+// blocked on it. The returned func must be called once the caller is done with the connection. It waits for the watcher
+// to finish and then clears the deadline, so a ctx cancelled just as the call returned can't break the next command.
+func (client *MCRCONClient) watchContext(ctx context.Context) func() {
+	conn := client.conn
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		select {
+		case <-ctx.Done():
+			// A deadline in the past wakes up anything currently blocked on the connection.
+			conn.SetDeadline(time.Unix(1, 0))
+		case <-done:
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-exited
+		conn.SetDeadline(time.Time{})
+	}
+}
+
+// contextError swaps err for a *TimeoutError if it was caused by ctx running out, or by the deadline taken from it.
+func (client *MCRCONClient) contextError(ctx context.Context, op string, err error) error {
+	if ctx.Err() != nil {
+		return &TimeoutError{Op: op, Err: ctx.Err()}
+	}
+	if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		return &TimeoutError{Op: op, Err: context.DeadlineExceeded}
+	}
+	return err
+}
+
+// fail handles an I/O error part way through a command. Whatever was left unread is still on the wire, so the connection
+// can't be trusted any more; close it and mark the client disconnected. Must be called with the client locked.
+func (client *MCRCONClient) fail(ctx context.Context, op string, err error) error {
+	client.Connected = false
+	client.conn.Close()
+	client.setState(StateDisconnected)
+	return client.contextError(ctx, op, err)
+}
+
+// readPacket decodes a single packet from the connection. Putting multi-packet responses back together is up to
+// roundTrip.
+func (client *MCRCONClient) readPacket() (Packet, error) {
+	return DecodePacket(client.rw)
+}
+
+// writePacket encodes pkt onto the connection and flushes it.
+func (client *MCRCONClient) writePacket(pkt Packet) error {
+	if err := EncodePacket(client.rw, pkt); err != nil {
+		return err
+	}
 	return client.rw.Flush()
 }
 
-func (client *MCRCONClient) buildPacket(id int, tp int, payload string) *MCRCONPacket {
-	// Build constructs an MCRCONPacket from raw information.
-	newPkt := MCRCONPacket{}
-	newPkt.length = int32(10 + len(payload))
-	newPkt.reqID = int32(id)
-	newPkt.reqType = int32(tp)
-	newPkt.Payload = payload
-	newPkt.nullPad[0] = 0
-	newPkt.nullPad[1] = 0
-	return &newPkt
+// newRequestID makes a pseudo-random, positive request ID. It leaves room for the following ID to be used as well, which
+// SendCommand relies on for its end-of-response sentinel.
+func newRequestID() int32 {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return rnd.Int31n(math.MaxInt32 - 1)
 }
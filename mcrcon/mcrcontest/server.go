@@ -0,0 +1,289 @@
+// mcrcontest provides a scriptable, in-process RCON server for testing code that talks to Minecraft, in the same spirit as
+// net/http/httptest. It speaks the same protocol as the vanilla server, including its auth failure behaviour, response
+// fragmentation and its answer to unknown packet types, and can be told to misbehave in the ways a real server does.
+package mcrcontest
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/joshproehl/minecontrol/mcrcon"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// HandlerFunc produces the response to a command. It's given the full command line as sent, minus any leading slash.
+type HandlerFunc func(command string) string
+
+// Server is a fake Minecraft RCON server listening on a local port. It's safe to reconfigure while clients are connected.
+type Server struct {
+	// Addr is the host:port the server is listening on.
+	Addr string
+
+	password string
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	m            sync.Mutex
+	handlers     map[string]HandlerFunc
+	conns        map[net.Conn]struct{}
+	received     []string
+	fragmentSize int
+	latency      time.Duration
+	stalled      chan struct{}
+	dropNext     int
+}
+
+// NewServer starts a server on a random port on the loopback interface which accepts logins with password. It panics if
+// it can't listen, since that's not something a test can do anything about. Call Close when done with it.
+func NewServer(password string) *Server {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("mcrcontest: failed to listen on a port: %v", err))
+	}
+
+	s := &Server{
+		Addr:         l.Addr().String(),
+		password:     password,
+		listener:     l,
+		handlers:     make(map[string]HandlerFunc),
+		conns:        make(map[net.Conn]struct{}),
+		fragmentSize: 4096,
+	}
+
+	s.wg.Add(1)
+	go s.serve()
+
+	return s
+}
+
+// Host returns the address the server is listening on, for passing to mcrcon.NewClient.
+func (s *Server) Host() string {
+	host, _, _ := net.SplitHostPort(s.Addr)
+	return host
+}
+
+// Port returns the port the server is listening on, for passing to mcrcon.NewClient.
+func (s *Server) Port() int {
+	_, port, _ := net.SplitHostPort(s.Addr)
+	p, _ := strconv.Atoi(port)
+	return p
+}
+
+// Handle registers fn to answer commands whose first word is name, e.g. "list" or "whitelist".
+func (s *Server) Handle(name string, fn HandlerFunc) {
+	s.m.Lock()
+	s.handlers[strings.ToLower(name)] = fn
+	s.m.Unlock()
+}
+
+// HandleResponse registers a canned response for commands whose first word is name.
+func (s *Server) HandleResponse(name, response string) {
+	s.Handle(name, func(string) string {
+		return response
+	})
+}
+
+// Received returns every command the server has been sent so far, in order.
+func (s *Server) Received() []string {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return append([]string(nil), s.received...)
+}
+
+// SetFragmentSize sets the most payload sent in one response packet. The vanilla server uses 4096, making it smaller
+// exercises multi-packet handling with shorter responses. Zero or less sends every response in a single packet.
+func (s *Server) SetFragmentSize(n int) {
+	s.m.Lock()
+	s.fragmentSize = n
+	s.m.Unlock()
+}
+
+// SetLatency delays every reply by d.
+func (s *Server) SetLatency(d time.Duration) {
+	s.m.Lock()
+	s.latency = d
+	s.m.Unlock()
+}
+
+// Stall stops the server replying to anything until Resume is called. Packets are still read, so clients see a server
+// that has hung rather than one that has gone away.
+func (s *Server) Stall() {
+	s.m.Lock()
+	if s.stalled == nil {
+		s.stalled = make(chan struct{})
+	}
+	s.m.Unlock()
+}
+
+// Resume undoes Stall, letting any replies that were held up go out.
+func (s *Server) Resume() {
+	s.m.Lock()
+	if s.stalled != nil {
+		close(s.stalled)
+		s.stalled = nil
+	}
+	s.m.Unlock()
+}
+
+// DropNext makes the server hang up, without replying, on the next n commands it receives.
+func (s *Server) DropNext(n int) {
+	s.m.Lock()
+	s.dropNext = n
+	s.m.Unlock()
+}
+
+// CloseConnections hangs up on every connected client but keeps listening, like a Minecraft server restarting.
+func (s *Server) CloseConnections() {
+	s.m.Lock()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.m.Unlock()
+}
+
+// Close stops listening, hangs up on every client and waits for the server's goroutines to finish.
+func (s *Server) Close() {
+	s.listener.Close()
+	s.Resume()
+	s.CloseConnections()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.m.Lock()
+		s.conns[conn] = struct{}{}
+		s.m.Unlock()
+
+		s.wg.Add(1)
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.m.Lock()
+		delete(s.conns, conn)
+		s.m.Unlock()
+		conn.Close()
+	}()
+
+	r := bufio.NewReader(conn)
+	authed := false
+
+	for {
+		pkt, err := mcrcon.DecodePacket(r)
+		if err != nil {
+			return
+		}
+
+		switch pkt.Type {
+		case mcrcon.PacketTypeLogin:
+			authed = pkt.Payload == s.password
+			id := pkt.ID
+			if !authed {
+				id = -1
+			}
+			if !s.reply(conn, mcrcon.Packet{ID: id, Type: mcrcon.PacketTypeCommand}) {
+				return
+			}
+
+		case mcrcon.PacketTypeCommand:
+			if !authed {
+				if !s.reply(conn, mcrcon.Packet{ID: -1, Type: mcrcon.PacketTypeResponse}) {
+					return
+				}
+				continue
+			}
+
+			if s.shouldDrop(pkt.Payload) {
+				return
+			}
+
+			if !s.respond(conn, pkt.ID, s.run(pkt.Payload)) {
+				return
+			}
+
+		default:
+			// This is what vanilla does, and it's what mcrcon.MCRCONClient relies on to find the end of a response.
+			if !s.reply(conn, mcrcon.Packet{ID: pkt.ID, Type: mcrcon.PacketTypeResponse, Payload: fmt.Sprintf("Unknown request %x", int32(pkt.Type))}) {
+				return
+			}
+		}
+	}
+}
+
+// shouldDrop records the command and reports whether the connection should be dropped instead of answering it.
+func (s *Server) shouldDrop(command string) bool {
+	s.m.Lock()
+	defer s.m.Unlock()
+
+	s.received = append(s.received, command)
+	if s.dropNext > 0 {
+		s.dropNext--
+		return true
+	}
+	return false
+}
+
+func (s *Server) run(command string) string {
+	command = strings.TrimPrefix(command, "/")
+	name := strings.ToLower(strings.SplitN(command, " ", 2)[0])
+
+	s.m.Lock()
+	fn, ok := s.handlers[name]
+	s.m.Unlock()
+
+	if !ok {
+		return fmt.Sprintf("Unknown or incomplete command, see below for error%s<--[HERE]", command)
+	}
+	return fn(command)
+}
+
+// respond splits a response into fragments and sends them all with the given request ID.
+func (s *Server) respond(conn net.Conn, id int32, response string) bool {
+	s.m.Lock()
+	size := s.fragmentSize
+	s.m.Unlock()
+
+	for {
+		n := len(response)
+		if size > 0 && n > size {
+			n = size
+		}
+		if !s.reply(conn, mcrcon.Packet{ID: id, Type: mcrcon.PacketTypeResponse, Payload: response[:n]}) {
+			return false
+		}
+		response = response[n:]
+		if response == "" {
+			return true
+		}
+	}
+}
+
+// reply sends one packet, after any configured latency or stall. It reports whether the connection is still usable.
+func (s *Server) reply(conn net.Conn, pkt mcrcon.Packet) bool {
+	s.m.Lock()
+	latency, stalled := s.latency, s.stalled
+	s.m.Unlock()
+
+	if stalled != nil {
+		<-stalled
+	}
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+
+	return mcrcon.EncodePacket(conn, pkt) == nil
+}
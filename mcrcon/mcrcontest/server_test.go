@@ -0,0 +1,181 @@
+package mcrcontest
+
+import (
+	"context"
+	"errors"
+	"github.com/joshproehl/minecontrol/mcrcon"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newClient(t *testing.T, s *Server) *mcrcon.MCRCONClient {
+	t.Helper()
+	c, err := mcrcon.NewClient(s.Host(), s.Port(), "secret")
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestFragmentedResponse(t *testing.T) {
+	s := NewServer("secret")
+	defer s.Close()
+
+	want := strings.Repeat("0123456789", 1000)
+	s.HandleResponse("help", want)
+
+	for _, size := range []int{4096, 100, 7} {
+		s.SetFragmentSize(size)
+		got, err := newClient(t, s).SendCommand("help")
+		if err != nil {
+			t.Fatalf("fragment size %d: %v", size, err)
+		}
+		if got != want {
+			t.Errorf("fragment size %d: got %d bytes back, want %d", size, len(got), len(want))
+		}
+	}
+
+	// No fragment size sends responses whole, which is only valid for ones that fit in a packet.
+	s.SetFragmentSize(0)
+	s.HandleResponse("seed", "Seed: [-4172144997902289642]")
+	if got, err := newClient(t, s).SendCommand("seed"); err != nil || got != "Seed: [-4172144997902289642]" {
+		t.Errorf("unfragmented: got %q, %v", got, err)
+	}
+}
+
+func TestAuthFailure(t *testing.T) {
+	s := NewServer("secret")
+	defer s.Close()
+
+	_, err := mcrcon.NewClient(s.Host(), s.Port(), "wrong")
+	if !errors.Is(err, mcrcon.ErrAuthFailed) {
+		t.Fatalf("got %v, want ErrAuthFailed", err)
+	}
+}
+
+func TestStalledServer(t *testing.T) {
+	s := NewServer("secret")
+	defer s.Close()
+	s.HandleResponse("list", "There are 0 of a max of 20 players online: ")
+
+	c := newClient(t, s)
+	s.Stall()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := c.SendCommandContext(ctx, "list")
+
+	var te *mcrcon.TimeoutError
+	if !errors.As(err, &te) {
+		t.Fatalf("got %v, want a *TimeoutError", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("took %s to time out", elapsed)
+	}
+	if c.Connected {
+		t.Error("client still marked connected after timing out")
+	}
+}
+
+func TestDroppedConnectionReconnects(t *testing.T) {
+	s := NewServer("secret")
+	defer s.Close()
+	s.HandleResponse("list", "There are 0 of a max of 20 players online: ")
+	s.HandleResponse("say", "")
+
+	c := newClient(t, s)
+	c.SetReconnectPolicy(&mcrcon.ReconnectPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 10 * time.Millisecond,
+		Replayable:     mcrcon.ReplayQueries,
+	})
+
+	var states []mcrcon.ConnState
+	c.OnStateChange(func(st mcrcon.ConnState) {
+		states = append(states, st)
+	})
+
+	// A query that's lost is replayed on the new connection.
+	s.DropNext(1)
+	got, err := c.SendCommand("list")
+	if err != nil {
+		t.Fatalf("list after drop: %v", err)
+	}
+	if !strings.HasPrefix(got, "There are 0") {
+		t.Errorf("list after drop: got %q", got)
+	}
+	if want := []string{"list", "list"}; strings.Join(s.Received(), ",") != strings.Join(want, ",") {
+		t.Errorf("server received %q, want %q", s.Received(), want)
+	}
+	if len(states) == 0 || states[len(states)-1] != mcrcon.StateConnected {
+		t.Errorf("states %v, want to end connected", states)
+	}
+
+	// Anything else reconnects but isn't run again, and the cause is kept.
+	s.DropNext(1)
+	_, err = c.SendCommand("say hi")
+	if !errors.Is(err, io.EOF) {
+		t.Fatalf("say after drop: got %v, want an error wrapping io.EOF", err)
+	}
+	if !c.Connected {
+		t.Error("client not reconnected after dropped say")
+	}
+
+	// A server restart in between commands is picked up by the next one.
+	s.CloseConnections()
+	if _, err := c.SendCommand("list"); err != nil {
+		t.Errorf("list after restart: %v", err)
+	}
+}
+
+func TestClosedClientDoesNotReconnect(t *testing.T) {
+	s := NewServer("secret")
+	defer s.Close()
+	s.HandleResponse("list", "There are 0 of a max of 20 players online: ")
+
+	c := newClient(t, s)
+	c.SetReconnectPolicy(mcrcon.DefaultReconnectPolicy())
+	c.Close()
+
+	if _, err := c.SendCommand("list"); !errors.Is(err, mcrcon.ErrClosed) {
+		t.Errorf("got %v, want ErrClosed", err)
+	}
+	if got := s.Received(); len(got) != 0 {
+		t.Errorf("closed client sent %q", got)
+	}
+}
+
+func TestCloseInterruptsReconnect(t *testing.T) {
+	s := NewServer("secret")
+	c := newClient(t, s)
+	c.SetReconnectPolicy(&mcrcon.ReconnectPolicy{InitialBackoff: 20 * time.Millisecond, MaxBackoff: 50 * time.Millisecond})
+
+	// With the server gone for good, and no limit on attempts, this would retry forever.
+	s.Close()
+	errs := make(chan error, 1)
+	go func() {
+		_, err := c.SendCommand("list")
+		errs <- err
+	}()
+	time.Sleep(100 * time.Millisecond)
+
+	closed := make(chan struct{})
+	go func() {
+		c.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-closed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close blocked behind the reconnect")
+	}
+	if err := <-errs; !errors.Is(err, mcrcon.ErrClosed) {
+		t.Errorf("got %v, want ErrClosed", err)
+	}
+}
@@ -0,0 +1,168 @@
+package mcrcon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// PacketType is the type field of an RCON packet.
+type PacketType int32
+
+const (
+	PacketTypeResponse PacketType = 0
+	PacketTypeCommand  PacketType = 2
+	PacketTypeLogin    PacketType = 3
+)
+
+/*
+Packet defines the structure of a single packet either to send to or recieve from the RCON server.
+
+From wiki.vg/Rcon:
+
+	Packet Format
+
+	Integers are little-endian, in contrast with the Beta protocol.
+	Responses are sent back with the same Request ID that you send.
+	In the event of an auth failure (i.e. your login is incorrect, or you're trying to send commands without first logging in), request ID will be set to -1
+
+	Field name  Field Type  Notes
+	Length      int         Length of remainder of packet
+	Request ID  int         Client-generated ID
+	Type        int         3 for login, 2 to run a command, 0 for a multi-packet response
+	Payload     byte[]      ASCII text
+	2-byte pad  byte, byte  Two null bytes
+
+	Packet Types
+
+	3: Login
+	  Outgoing payload: password.
+	  If the server returns a packet with the same request ID, auth was successful (note: packet type is 2, not 3). If you get an request ID of -1, auth failed (wrong password).
+	2: Command
+	  Outgoing payload should be the command to run, e.g. time set 0
+	0: Command response
+	  Incoming payload is the output of the command, though many commands return nothing, and there's no way of detecting unknown commands.
+	  The output of the command may be split over multiple packets, each containing 4096 bytes (less for the last packet). Each packet contains part of the payload (and the two-byte padding). The last packet sent is the end of the output.
+
+	Maximum request length: 1460 (giving a max payload length of 1446)
+	Code exists in the notchian server to split large responses (>4096 bytes) into multiple smaller packets. However, the code that actually encodes each packet expects a max length of 1248, giving a max response payload length of 1234 bytes.
+*/
+type Packet struct {
+	ID      int32
+	Type    PacketType
+	Payload string
+}
+
+// MCRCONPacket is the old name for Packet.
+//
+// Deprecated: Use Packet.
+type MCRCONPacket = Packet
+
+// maxFragmentLength is the most payload the server will put in a single response packet.
+const maxFragmentLength = 4096
+
+// Bounds on the length field of a packet. It counts the request ID, type and null pad as well as the payload.
+const (
+	minPacketLength = 10
+	maxPacketLength = maxFragmentLength + 10
+)
+
+// ErrMalformedPacket is returned when a packet doesn't follow the RCON framing rules.
+var ErrMalformedPacket = errors.New("malformed RCON packet")
+
+// EncodePacket writes p to w in the RCON wire format, in a single Write call.
+func EncodePacket(w io.Writer, p Packet) error {
+	data, err := p.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// DecodePacket reads a single packet from r. The length field is checked against the protocol limits before anything is
+// allocated, which also enforces the 4096 byte limit on response fragments. Errors wrapping ErrMalformedPacket are
+// returned for bad framing, in which case r can't be relied on to be at a packet boundary any more.
+func DecodePacket(r io.Reader) (Packet, error) {
+	var length int32
+	if err := binary.Read(r, binary.LittleEndian, &length); err != nil {
+		return Packet{}, err
+	}
+
+	if err := checkLength(length); err != nil {
+		return Packet{}, err
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return Packet{}, err
+	}
+
+	return decodeBody(body)
+}
+
+// MarshalBinary returns p in the RCON wire format, length prefix included.
+func (p Packet) MarshalBinary() ([]byte, error) {
+	length := int32(len(p.Payload) + minPacketLength)
+	if err := checkLength(length); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.Grow(int(length) + 4)
+	binary.Write(&buf, binary.LittleEndian, length)
+	binary.Write(&buf, binary.LittleEndian, p.ID)
+	binary.Write(&buf, binary.LittleEndian, p.Type)
+	buf.WriteString(p.Payload)
+	buf.Write([]byte{0, 0})
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary sets p from data, which must hold exactly one packet in the RCON wire format, length prefix included.
+func (p *Packet) UnmarshalBinary(data []byte) error {
+	if len(data) < 4 {
+		return fmt.Errorf("%w: %d bytes is too short", ErrMalformedPacket, len(data))
+	}
+
+	length := int32(binary.LittleEndian.Uint32(data))
+	if err := checkLength(length); err != nil {
+		return err
+	}
+	if int(length) != len(data)-4 {
+		return fmt.Errorf("%w: length field says %d bytes, have %d", ErrMalformedPacket, length, len(data)-4)
+	}
+
+	pkt, err := decodeBody(data[4:])
+	if err != nil {
+		return err
+	}
+	*p = pkt
+	return nil
+}
+
+func checkLength(length int32) error {
+	if length < minPacketLength || length > maxPacketLength {
+		return fmt.Errorf("%w: length %d outside %d..%d", ErrMalformedPacket, length, minPacketLength, maxPacketLength)
+	}
+	return nil
+}
+
+// decodeBody decodes everything after the length field: request ID, type, payload and the two null bytes of padding.
+func decodeBody(body []byte) (Packet, error) {
+	n := len(body)
+	if body[n-2] != 0 || body[n-1] != 0 {
+		return Packet{}, fmt.Errorf("%w: pad bytes are %#v, not null", ErrMalformedPacket, body[n-2:])
+	}
+
+	return Packet{
+		ID:      int32(binary.LittleEndian.Uint32(body[0:])),
+		Type:    PacketType(binary.LittleEndian.Uint32(body[4:])),
+		Payload: string(body[8 : n-2]),
+	}, nil
+}
@@ -0,0 +1,174 @@
+package mcrcon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+// rawPacket builds a packet by hand, so tests can get the framing wrong on purpose.
+func rawPacket(length int32, id int32, typ PacketType, payload string, pad []byte) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, length)
+	binary.Write(&buf, binary.LittleEndian, id)
+	binary.Write(&buf, binary.LittleEndian, typ)
+	buf.WriteString(payload)
+	buf.Write(pad)
+	return buf.Bytes()
+}
+
+func TestPacketRoundTrip(t *testing.T) {
+	for _, p := range []Packet{
+		{ID: 1, Type: PacketTypeLogin, Payload: "secret"},
+		{ID: 42, Type: PacketTypeCommand, Payload: "time set 0"},
+		{ID: 7, Type: PacketTypeResponse, Payload: ""},
+		{ID: -1, Type: PacketTypeCommand, Payload: ""},
+		{ID: 2147483647, Type: PacketTypeResponse, Payload: strings.Repeat("x", maxFragmentLength)},
+		{ID: 3, Type: PacketTypeResponse, Payload: "§6There are §c0§6 players"},
+	} {
+		var buf bytes.Buffer
+		if err := EncodePacket(&buf, p); err != nil {
+			t.Fatalf("EncodePacket(%+v): %v", p, err)
+		}
+		if want := len(p.Payload) + 14; buf.Len() != want {
+			t.Errorf("EncodePacket(%+v) wrote %d bytes, want %d", p, buf.Len(), want)
+		}
+
+		data := append([]byte(nil), buf.Bytes()...)
+
+		got, err := DecodePacket(&buf)
+		if err != nil {
+			t.Fatalf("DecodePacket: %v", err)
+		}
+		if got != p {
+			t.Errorf("DecodePacket = %+v, want %+v", got, p)
+		}
+
+		var u Packet
+		if err := u.UnmarshalBinary(data); err != nil {
+			t.Fatalf("UnmarshalBinary: %v", err)
+		}
+		if u != p {
+			t.Errorf("UnmarshalBinary = %+v, want %+v", u, p)
+		}
+	}
+}
+
+func TestDecodePacket(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    []byte
+		want    Packet
+		wantErr error
+	}{
+		{
+			name: "shortest, length 10",
+			data: rawPacket(10, 5, PacketTypeResponse, "", []byte{0, 0}),
+			want: Packet{ID: 5, Type: PacketTypeResponse},
+		},
+		{
+			name: "longest, length 4106",
+			data: rawPacket(4106, 5, PacketTypeResponse, strings.Repeat("a", 4096), []byte{0, 0}),
+			want: Packet{ID: 5, Type: PacketTypeResponse, Payload: strings.Repeat("a", 4096)},
+		},
+		{
+			name: "auth failure ID",
+			data: rawPacket(10, -1, PacketTypeCommand, "", []byte{0, 0}),
+			want: Packet{ID: -1, Type: PacketTypeCommand},
+		},
+		{
+			name:    "length 9",
+			data:    rawPacket(9, 5, PacketTypeResponse, "", []byte{0}),
+			wantErr: ErrMalformedPacket,
+		},
+		{
+			name:    "length 4107",
+			data:    rawPacket(4107, 5, PacketTypeResponse, strings.Repeat("a", 4097), []byte{0, 0}),
+			wantErr: ErrMalformedPacket,
+		},
+		{
+			name:    "negative length",
+			data:    rawPacket(-1, 5, PacketTypeResponse, "", []byte{0, 0}),
+			wantErr: ErrMalformedPacket,
+		},
+		{
+			name:    "non-zero pad",
+			data:    rawPacket(12, 5, PacketTypeResponse, "hi", []byte{0, 1}),
+			wantErr: ErrMalformedPacket,
+		},
+		{
+			name:    "missing pad",
+			data:    rawPacket(12, 5, PacketTypeResponse, "hi", nil),
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "payload where the pad should be",
+			data:    rawPacket(12, 5, PacketTypeResponse, "hiya", nil),
+			wantErr: ErrMalformedPacket,
+		},
+		{
+			name:    "truncated length",
+			data:    []byte{10, 0},
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "truncated body",
+			data:    rawPacket(10, 5, PacketTypeResponse, "", nil)[:9],
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "nothing",
+			data:    nil,
+			wantErr: io.EOF,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodePacket(bytes.NewReader(tt.data))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("DecodePacket = %+v, %v, want error %v", got, err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecodePacket: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DecodePacket = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalBinaryFraming(t *testing.T) {
+	good := rawPacket(12, 5, PacketTypeResponse, "hi", []byte{0, 0})
+
+	for name, data := range map[string][]byte{
+		"too short for a length": good[:3],
+		"truncated":              good[:len(good)-1],
+		"trailing bytes":         append(append([]byte(nil), good...), 0),
+		"non-zero pad":           rawPacket(12, 5, PacketTypeResponse, "hi", []byte{1, 0}),
+		"length 9":               rawPacket(9, 5, PacketTypeResponse, "", []byte{0}),
+	} {
+		var p Packet
+		if err := p.UnmarshalBinary(data); !errors.Is(err, ErrMalformedPacket) {
+			t.Errorf("%s: UnmarshalBinary = %v, want ErrMalformedPacket", name, err)
+		}
+	}
+}
+
+func TestEncodePacketTooLong(t *testing.T) {
+	var buf bytes.Buffer
+	err := EncodePacket(&buf, Packet{ID: 1, Type: PacketTypeCommand, Payload: strings.Repeat("a", maxFragmentLength+1)})
+	if !errors.Is(err, ErrMalformedPacket) {
+		t.Errorf("EncodePacket = %v, want ErrMalformedPacket", err)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("EncodePacket wrote %d bytes of a packet it refused", buf.Len())
+	}
+}
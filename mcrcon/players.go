@@ -0,0 +1,325 @@
+package mcrcon
+
+import (
+	"context"
+	"fmt"
+	"github.com/joshproehl/minecontrol/formatting"
+	"github.com/joshproehl/minecontrol/snbt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Commander is anything that can run a command on the server, such as an MCRCONClient or a Pool. Helpers that build on
+// commands take one of these so they work with either.
+type Commander interface {
+	SendCommandContext(ctx context.Context, cmd string) (string, error)
+}
+
+// Player is someone online on the server. UUID is only known when the server supports "list uuids".
+type Player struct {
+	Name string `json:"name"`
+	UUID string `json:"uuid,omitempty"`
+}
+
+// PlayerList is the parsed response to the list command.
+type PlayerList struct {
+	Online  int      `json:"online"`
+	Max     int      `json:"max"`
+	Players []Player `json:"players"`
+}
+
+var (
+	// The headers the list command has used over the years:
+	//   1.7 - 1.12, CraftBukkit:  There are 2/20 players online:
+	//   1.13 - 1.15:              There are 2 of a max 20 players online:
+	//   1.16+, Paper:             There are 2 of a max of 20 players online:
+	//   Essentials:               There are 2 out of maximum 20 players online.
+	//   Essentials, with hidden:  There are 2/1 out of maximum 20 players online.
+	listHeaderRes = []*regexp.Regexp{
+		regexp.MustCompile(`There are (\d+)(?:/\d+)? (?:of a max(?: of)?|out of (?:a )?maximum) (\d+) players online[.:]?`),
+		regexp.MustCompile(`There are (\d+)/(\d+) players online[.:]?`),
+	}
+
+	// A name from "list uuids", e.g. "Notch (069a79f4-44e9-4726-a5be-fca90e38aaf5)"
+	listUUIDRe = regexp.MustCompile(`^(\S+) \(([0-9a-fA-F-]{32,36})\)$`)
+
+	// Tags plugins put next to names, like [AFK] or [HIDDEN]
+	listTagRe = regexp.MustCompile(`\[[^\]]*\]`)
+)
+
+// PlayerDetails is everything the server will tell us about a player over RCON. The fields from the player's entity
+// data are only filled in while they're online.
+type PlayerDetails struct {
+	Name        string      `json:"name"`
+	UUID        string      `json:"uuid,omitempty"`
+	Online      bool        `json:"online"`
+	Position    *[3]float64 `json:"position,omitempty"`
+	Dimension   string      `json:"dimension,omitempty"`
+	Health      *float64    `json:"health,omitempty"`
+	Food        *int        `json:"food,omitempty"`
+	XPLevel     *int        `json:"xpLevel,omitempty"`
+	GameMode    string      `json:"gameMode,omitempty"`
+	Whitelisted bool        `json:"whitelisted"`
+	Banned      bool        `json:"banned"`
+
+	// There's no command that tells us who's an op, or who has played on the server before, so these are left for the
+	// caller to fill in, e.g. from ops.json and usercache.json. nil means unknown.
+	Op     *bool `json:"op"`
+	Played *bool `json:"played"`
+}
+
+// Known reports whether the server has heard of the player at all.
+func (pd *PlayerDetails) Known() bool {
+	return pd.Online || pd.Whitelisted || pd.Banned || (pd.Op != nil && *pd.Op) || (pd.Played != nil && *pd.Played)
+}
+
+var (
+	// Valid player names, which is also what keeps them safe to put into commands.
+	playerNameRe = regexp.MustCompile(`^[A-Za-z0-9_]{1,16}$`)
+
+	// "There are 2 whitelisted players: alice, bob", or "player(s)" before 1.13
+	whitelistRe = regexp.MustCompile(`whitelisted player(?:s|\(s\))?:(.*)`)
+
+	// Each ban in "There are 2 ban(s):alice was banned by Server: Banned by an operator.bob was banned by Server: x",
+	// which is "<name> was banned by <source>: <reason>". The entries aren't separated by anything over RCON, so a
+	// reason ending in a letter or digit runs straight into the next name.
+	banEntryRe = regexp.MustCompile(`([A-Za-z0-9_]{1,16}) was banned by [^:]*: `)
+
+	// The pre-1.13 format, "There are 2 total banned players:alice, bob"
+	oldBanListRe = regexp.MustCompile(`total banned players:(.*)`)
+
+	// playerGameType values
+	gameModes = []string{"survival", "creative", "adventure", "spectator"}
+
+	// Dimension values before 1.16, when they became names
+	oldDimensions = map[int64]string{-1: "minecraft:the_nether", 0: "minecraft:overworld", 1: "minecraft:the_end"}
+)
+
+// ValidPlayerName reports whether name is a valid Minecraft player name.
+func ValidPlayerName(name string) bool {
+	return playerNameRe.MatchString(name)
+}
+
+// GetPlayerDetails looks up everything the server can tell us about the named player.
+func GetPlayerDetails(ctx context.Context, c Commander, name string) (*PlayerDetails, error) {
+	if !ValidPlayerName(name) {
+		return nil, fmt.Errorf("Invalid player name %q", name)
+	}
+
+	pd := &PlayerDetails{Name: name}
+
+	pl, err := ListPlayers(ctx, c)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range pl.Players {
+		if strings.EqualFold(p.Name, name) {
+			pd.Name, pd.UUID, pd.Online = p.Name, p.UUID, true
+		}
+	}
+
+	if pd.Online {
+		// It's fine for this to fail, e.g. on servers from before "data get" existed.
+		if data, err := DataGet(ctx, c, "entity "+pd.Name, ""); err == nil {
+			pd.setEntityData(data)
+		}
+	}
+
+	response, err := c.SendCommandContext(ctx, "whitelist list")
+	if err != nil {
+		return nil, err
+	}
+	pd.Whitelisted = containsName(ParseWhitelist(response), pd.Name)
+
+	response, err = c.SendCommandContext(ctx, "banlist players")
+	if err != nil {
+		return nil, err
+	}
+	pd.Banned = BanListHas(response, pd.Name)
+
+	return pd, nil
+}
+
+// setEntityData fills in the fields that come from the player's entity data.
+func (pd *PlayerDetails) setEntityData(data snbt.Tag) {
+	if pos, err := snbt.Get(data, "Pos"); err == nil && pos.Kind() == snbt.KindList && len(pos.(snbt.List)) == 3 {
+		var xyz [3]float64
+		for i, v := range pos.(snbt.List) {
+			xyz[i], _ = snbt.Float64(v)
+		}
+		pd.Position = &xyz
+	}
+
+	if dim, err := snbt.Get(data, "Dimension"); err == nil {
+		if d, ok := snbt.Int64(dim); ok {
+			pd.Dimension = oldDimensions[d]
+		} else if d, ok := dim.(snbt.String); ok {
+			pd.Dimension = string(d)
+		}
+	}
+
+	if h, err := snbt.Get(data, "Health"); err == nil {
+		if health, ok := snbt.Float64(h); ok {
+			pd.Health = &health
+		}
+	}
+
+	if f, err := snbt.Get(data, "foodLevel"); err == nil {
+		if food, ok := snbt.Int64(f); ok {
+			n := int(food)
+			pd.Food = &n
+		}
+	}
+
+	if x, err := snbt.Get(data, "XpLevel"); err == nil {
+		if xp, ok := snbt.Int64(x); ok {
+			n := int(xp)
+			pd.XPLevel = &n
+		}
+	}
+
+	if g, err := snbt.Get(data, "playerGameType"); err == nil {
+		if mode, ok := snbt.Int64(g); ok && mode >= 0 && int(mode) < len(gameModes) {
+			pd.GameMode = gameModes[mode]
+		}
+	}
+}
+
+// ParseWhitelist gets the names out of the response to "whitelist list".
+func ParseWhitelist(response string) []string {
+	m := whitelistRe.FindStringSubmatch(formatting.Strip(response))
+	if m == nil {
+		return nil
+	}
+	return splitNames(m[1])
+}
+
+// ParseBanList gets the names out of the response to "banlist players". Where a ban reason runs into the next name
+// there's no telling where one ends and the other starts, and the name comes back with the end of the reason on the
+// front; use BanListHas to look for a particular player, or the server's banned-players.json if it can be read.
+func ParseBanList(response string) []string {
+	response = formatting.Strip(response)
+
+	if m := oldBanListRe.FindStringSubmatch(response); m != nil {
+		return splitNames(m[1])
+	}
+
+	var names []string
+	for _, m := range banEntryRe.FindAllStringSubmatch(response, -1) {
+		names = append(names, m[1])
+	}
+	return names
+}
+
+// BanListHas reports whether the response to "banlist players" includes a ban on name. It goes by the text right before
+// each " was banned by <source>: ", which must be name with something other than a letter, digit or underscore in
+// front, like the full stop ending the previous ban's reason. A reason that runs straight into the next name can't be
+// told apart from a longer name, so a ban on "superbob" doesn't count as a ban on "bob".
+func BanListHas(response, name string) bool {
+	response = formatting.Strip(response)
+
+	if m := oldBanListRe.FindStringSubmatch(response); m != nil {
+		return containsName(splitNames(m[1]), name)
+	}
+
+	for _, loc := range banEntryRe.FindAllStringSubmatchIndex(response, -1) {
+		start := loc[3] - len(name)
+		if start < 0 || !strings.EqualFold(response[start:loc[3]], name) {
+			continue
+		}
+		if start == 0 || !isNameChar(response[start-1]) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_'
+}
+
+func splitNames(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n'
+	})
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsePlayerList parses the response to "list" or "list uuids" from vanilla, CraftBukkit, Spigot, Paper or Essentials.
+// Formatting codes are ignored. Names come after the header either all together, as
+//
+//	There are 2 of a max of 20 players online: alice, bob
+//
+// or, from Essentials, a line per group:
+//
+//	There are 2 out of maximum 20 players online.
+//	admins: alice
+//	default: [AFK]bob
+func ParsePlayerList(response string) (*PlayerList, error) {
+	response = formatting.Strip(response)
+
+	var loc []int
+	for _, re := range listHeaderRes {
+		if loc = re.FindStringSubmatchIndex(response); loc != nil {
+			break
+		}
+	}
+	if loc == nil {
+		return nil, fmt.Errorf("Unrecognised player list response: %q", response)
+	}
+
+	pl := &PlayerList{Players: []Player{}}
+	pl.Online, _ = strconv.Atoi(response[loc[2]:loc[3]])
+	pl.Max, _ = strconv.Atoi(response[loc[4]:loc[5]])
+
+	for _, line := range strings.Split(response[loc[1]:], "\n") {
+		// Names can't contain colons, so anything before one is an Essentials group name.
+		if i := strings.LastIndex(line, ":"); i >= 0 {
+			line = line[i+1:]
+		}
+
+		for _, entry := range strings.Split(line, ",") {
+			entry = strings.TrimSpace(listTagRe.ReplaceAllString(entry, ""))
+			entry = strings.TrimPrefix(entry, "~") // Essentials marks nicknames like this
+			if entry == "" {
+				continue
+			}
+
+			if m := listUUIDRe.FindStringSubmatch(entry); m != nil {
+				pl.Players = append(pl.Players, Player{Name: m[1], UUID: m[2]})
+			} else {
+				pl.Players = append(pl.Players, Player{Name: strings.Fields(entry)[0]})
+			}
+		}
+	}
+
+	return pl, nil
+}
+
+// ListPlayers asks the server who's online. It tries "list uuids" first, falling back to plain "list" on servers that
+// don't support it.
+func ListPlayers(ctx context.Context, c Commander) (*PlayerList, error) {
+	response, err := c.SendCommandContext(ctx, "list uuids")
+	if err != nil {
+		return nil, err
+	}
+
+	if pl, err := ParsePlayerList(response); err == nil {
+		return pl, nil
+	}
+
+	response, err = c.SendCommandContext(ctx, "list")
+	if err != nil {
+		return nil, err
+	}
+	return ParsePlayerList(response)
+}
@@ -0,0 +1,167 @@
+package mcrcon
+
+import (
+	"reflect"
+	"testing"
+)
+
+func names(players ...string) []Player {
+	pl := []Player{}
+	for _, n := range players {
+		pl = append(pl, Player{Name: n})
+	}
+	return pl
+}
+
+func TestParsePlayerList(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		want     PlayerList
+	}{
+		{
+			name:     "vanilla 1.12",
+			response: "There are 2/20 players online:alice, bob",
+			want:     PlayerList{Online: 2, Max: 20, Players: names("alice", "bob")},
+		},
+		{
+			name:     "vanilla 1.12 with names on their own line",
+			response: "There are 2/20 players online:\nalice, bob",
+			want:     PlayerList{Online: 2, Max: 20, Players: names("alice", "bob")},
+		},
+		{
+			name:     "vanilla 1.12 empty",
+			response: "There are 0/20 players online:",
+			want:     PlayerList{Online: 0, Max: 20, Players: names()},
+		},
+		{
+			name:     "vanilla 1.13",
+			response: "There are 2 of a max 20 players online: alice, bob",
+			want:     PlayerList{Online: 2, Max: 20, Players: names("alice", "bob")},
+		},
+		{
+			name:     "vanilla 1.16+",
+			response: "There are 3 of a max of 20 players online: alice, bob, Carol_99",
+			want:     PlayerList{Online: 3, Max: 20, Players: names("alice", "bob", "Carol_99")},
+		},
+		{
+			name:     "vanilla 1.16+ empty",
+			response: "There are 0 of a max of 20 players online: ",
+			want:     PlayerList{Online: 0, Max: 20, Players: names()},
+		},
+		{
+			name:     "list uuids",
+			response: "There are 2 of a max of 20 players online: Notch (069a79f4-44e9-4726-a5be-fca90e38aaf5), jeb_ (853c80ef-3c37-49fd-aa49-938b674adae6)",
+			want: PlayerList{Online: 2, Max: 20, Players: []Player{
+				{Name: "Notch", UUID: "069a79f4-44e9-4726-a5be-fca90e38aaf5"},
+				{Name: "jeb_", UUID: "853c80ef-3c37-49fd-aa49-938b674adae6"},
+			}},
+		},
+		{
+			name:     "CraftBukkit",
+			response: "There are 1/50 players online:\nNotch",
+			want:     PlayerList{Online: 1, Max: 50, Players: names("Notch")},
+		},
+		{
+			name:     "Spigot with formatting codes",
+			response: "§6There are §c2§6/§c100§6 players online:\n§falice§r, §fbob§r",
+			want:     PlayerList{Online: 2, Max: 100, Players: names("alice", "bob")},
+		},
+		{
+			name:     "Paper with formatting codes",
+			response: "There are §c2§r of a max of §c20§r players online: §falice§r, §7bob",
+			want:     PlayerList{Online: 2, Max: 20, Players: names("alice", "bob")},
+		},
+		{
+			name:     "Essentials groups",
+			response: "§6There are §c3§6 out of maximum §c20§6 players online.\n§6admins§r: §falice\n§6default§r: §7[AFK]§r§fbob§f, §fcarol",
+			want:     PlayerList{Online: 3, Max: 20, Players: names("alice", "bob", "carol")},
+		},
+		{
+			name:     "Essentials with hidden players and nicknames",
+			response: "§6There are §c2§6/§c1§6 out of maximum §c20§6 players online.\n§6Players§r: §f~Ally§f, §7[HIDDEN]§r§fbob",
+			want:     PlayerList{Online: 2, Max: 20, Players: names("Ally", "bob")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePlayerList(tt.response)
+			if err != nil {
+				t.Fatalf("ParsePlayerList(%q): %v", tt.response, err)
+			}
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("ParsePlayerList(%q)\n got  %+v\n want %+v", tt.response, *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePlayerListUnrecognised(t *testing.T) {
+	for _, response := range []string{
+		"",
+		"Unknown or incomplete command, see below for error",
+		"Unknown command. Type \"/help\" for help.",
+	} {
+		if pl, err := ParsePlayerList(response); err == nil {
+			t.Errorf("ParsePlayerList(%q) = %+v, want an error", response, pl)
+		}
+	}
+}
+
+func TestBanListHas(t *testing.T) {
+	// Over RCON the entries aren't separated, so each name runs on from the previous ban's reason.
+	response := "There are 4 ban(s):alice was banned by Server: Banned by an operator.bob was banned by Rcon: griefing!superbob was banned by Notch: x_y was banned by Notch: spam"
+
+	for _, tt := range []struct {
+		name string
+		want bool
+	}{
+		{"alice", true},
+		{"ALICE", true},
+		{"bob", true},
+		{"superbob", true},
+		{"perbob", false},
+		{"griefing", false},
+		{"y", false},
+		{"Notch", false},
+		{"Server", false},
+		{"carol", false},
+	} {
+		if got := BanListHas(response, tt.name); got != tt.want {
+			t.Errorf("BanListHas(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	// "bob" is only a suffix of the name that was banned.
+	if BanListHas("There are 1 ban(s):superbob was banned by Server: Banned by an operator.", "bob") {
+		t.Error("a ban on superbob counted as a ban on bob")
+	}
+
+	if BanListHas("There are no bans", "alice") {
+		t.Error("BanListHas found a ban in an empty ban list")
+	}
+
+	old := "There are 2 total banned players:alice, bob"
+	if !BanListHas(old, "bob") || BanListHas(old, "carol") {
+		t.Errorf("BanListHas got the pre-1.13 ban list wrong")
+	}
+}
+
+func TestParseBanList(t *testing.T) {
+	tests := []struct {
+		response string
+		want     []string
+	}{
+		{"There are 2 ban(s):alice was banned by Server: Banned by an operator.bob was banned by Notch: Spamming.", []string{"alice", "bob"}},
+		{"§6There are 1 ban(s):§falice was banned by Server: Banned by an operator.", []string{"alice"}},
+		{"There are 2 total banned players:alice, bob", []string{"alice", "bob"}},
+		{"There are no bans", nil},
+	}
+
+	for _, tt := range tests {
+		if got := ParseBanList(tt.response); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseBanList(%q) = %q, want %q", tt.response, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,256 @@
+package mcrcon
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrPoolExhausted is returned by Pool.Do when no connection became free within PoolConfig.MaxWait.
+	ErrPoolExhausted = errors.New("mcrcon: timed out waiting for a free pooled connection")
+
+	// ErrPoolClosed is returned by Pool.Do once the pool has been closed.
+	ErrPoolClosed = errors.New("mcrcon: pool closed")
+)
+
+// PoolConfig sets the limits of a Pool. Zero values get the defaults noted below.
+type PoolConfig struct {
+	Size                int           // Most connections open at once. Default 4.
+	MaxWait             time.Duration // How long Do waits for a free connection. 0 means as long as its context allows.
+	IdleTimeout         time.Duration // Connections unused for this long are closed. 0 means never.
+	HealthCheckInterval time.Duration // How often idle connections are pinged and evicted. Default 30 seconds.
+}
+
+// Pool keeps up to Size authenticated connections to one RCON server, so slow commands don't hold up everything else
+// the way they do when sharing a single MCRCONClient. Connections are made as they're needed, checked while idle, and
+// replaced if they break. Pool is safe for concurrent use.
+type Pool struct {
+	addr   string
+	port   int
+	passwd string
+	cfg    PoolConfig
+
+	// tokens holds one entry for each connection that's checked out, or being dialled or health checked, which keeps
+	// the total at or under Size.
+	tokens chan struct{}
+	done   chan struct{}
+
+	m      sync.Mutex
+	idle   []*pooledClient // Least recently used first
+	closed bool
+}
+
+type pooledClient struct {
+	client   *MCRCONClient
+	lastUsed time.Time
+}
+
+// NewPool makes a pool of connections to the RCON server at addr:port. No connections are made until they're needed;
+// use Ping to check the server can be reached.
+func NewPool(addr string, port int, passwd string, cfg PoolConfig) *Pool {
+	if cfg.Size <= 0 {
+		cfg.Size = 4
+	}
+	if cfg.HealthCheckInterval <= 0 {
+		cfg.HealthCheckInterval = 30 * time.Second
+	}
+
+	p := &Pool{
+		addr:   addr,
+		port:   port,
+		passwd: passwd,
+		cfg:    cfg,
+		tokens: make(chan struct{}, cfg.Size),
+		done:   make(chan struct{}),
+	}
+
+	go p.maintain()
+
+	return p
+}
+
+// Do runs cmd on one of the pool's connections and returns the response.
+func (p *Pool) Do(ctx context.Context, cmd string) (string, error) {
+	client, err := p.get(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	response, err := client.SendCommandContext(ctx, cmd)
+	p.put(client)
+
+	return response, err
+}
+
+// SendCommandContext is the same as Do, so a Pool can be used as a Commander.
+func (p *Pool) SendCommandContext(ctx context.Context, cmd string) (string, error) {
+	return p.Do(ctx, cmd)
+}
+
+// Ping checks that a pooled connection can be made and answers, dialling one if none are open.
+func (p *Pool) Ping(ctx context.Context) error {
+	client, err := p.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = client.Ping(ctx)
+	p.put(client)
+
+	return err
+}
+
+// Close closes every idle connection and stops the pool. Connections that are in use are closed as they're returned.
+func (p *Pool) Close() {
+	p.m.Lock()
+	if p.closed {
+		p.m.Unlock()
+		return
+	}
+	p.closed = true
+	idle := p.idle
+	p.idle = nil
+	p.m.Unlock()
+
+	close(p.done)
+	for _, pc := range idle {
+		pc.client.Close()
+	}
+}
+
+// get checks out a connection, reusing the most recently used idle one or dialling a new one.
+func (p *Pool) get(ctx context.Context) (*MCRCONClient, error) {
+	waitCtx := ctx
+	if p.cfg.MaxWait > 0 {
+		var cancel context.CancelFunc
+		waitCtx, cancel = context.WithTimeout(ctx, p.cfg.MaxWait)
+		defer cancel()
+	}
+
+	select {
+	case p.tokens <- struct{}{}:
+	case <-waitCtx.Done():
+		if ctx.Err() != nil {
+			return nil, &TimeoutError{Op: "pool wait", Err: ctx.Err()}
+		}
+		return nil, ErrPoolExhausted
+	}
+
+	p.m.Lock()
+	if p.closed {
+		p.m.Unlock()
+		<-p.tokens
+		return nil, ErrPoolClosed
+	}
+	if n := len(p.idle); n > 0 {
+		pc := p.idle[n-1]
+		p.idle = p.idle[:n-1]
+		p.m.Unlock()
+		return pc.client, nil
+	}
+	p.m.Unlock()
+
+	client, err := NewClientContext(ctx, p.addr, p.port, p.passwd)
+	if err != nil {
+		<-p.tokens
+		return nil, err
+	}
+	return client, nil
+}
+
+// put returns a checked out connection, throwing it away if it has broken.
+func (p *Pool) put(client *MCRCONClient) {
+	p.m.Lock()
+	if client.Connected && !p.closed {
+		p.idle = append(p.idle, &pooledClient{client: client, lastUsed: time.Now()})
+		client = nil
+	}
+	p.m.Unlock()
+
+	if client != nil {
+		client.Close()
+	}
+	<-p.tokens
+}
+
+// maintain periodically evicts and health checks idle connections until the pool is closed.
+func (p *Pool) maintain() {
+	t := time.NewTicker(p.cfg.HealthCheckInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-t.C:
+			p.checkIdle()
+		}
+	}
+}
+
+// checkIdle goes through the connections that were idle when it started, oldest first. Each is closed if it has been
+// idle too long or doesn't answer a ping. The ones that pass are held aside, so the next pass doesn't pick the same one
+// up again, and put back together at the old end of the queue once everything has been checked.
+func (p *Pool) checkIdle() {
+	p.m.Lock()
+	n := len(p.idle)
+	p.m.Unlock()
+
+	// Each connection held aside keeps its token until it's back in the idle list, so Do can't go over Size by dialling
+	// in the meantime.
+	var checked []*pooledClient
+	defer func() {
+		p.m.Lock()
+		closed := p.closed
+		if !closed {
+			// Keeping their last used times so they still age out.
+			p.idle = append(checked, p.idle...)
+		}
+		p.m.Unlock()
+
+		for _, pc := range checked {
+			if closed {
+				pc.client.Close()
+			}
+			<-p.tokens
+		}
+	}()
+
+	for i := 0; i < n; i++ {
+		// If the pool is fully busy there's nothing idle to check anyway.
+		select {
+		case p.tokens <- struct{}{}:
+		default:
+			return
+		}
+
+		p.m.Lock()
+		if p.closed || len(p.idle) == 0 {
+			p.m.Unlock()
+			<-p.tokens
+			return
+		}
+		pc := p.idle[0]
+		p.idle = p.idle[1:]
+		p.m.Unlock()
+
+		if p.cfg.IdleTimeout > 0 && time.Since(pc.lastUsed) > p.cfg.IdleTimeout {
+			pc.client.Close()
+			<-p.tokens
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		err := pc.client.Ping(ctx)
+		cancel()
+		if err != nil {
+			pc.client.Close()
+			<-p.tokens
+			continue
+		}
+
+		checked = append(checked, pc)
+	}
+}
@@ -0,0 +1,128 @@
+package mcrcon_test
+
+import (
+	"context"
+	"github.com/joshproehl/minecontrol/mcrcon"
+	"github.com/joshproehl/minecontrol/mcrcon/mcrcontest"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingRelay sits between the pool and the server, counting the bytes each connection sends, so the test can tell
+// which connections were pinged.
+type countingRelay struct {
+	listener net.Listener
+
+	m      sync.Mutex
+	counts []*int64
+}
+
+func newCountingRelay(t *testing.T, target string) *countingRelay {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := &countingRelay{listener: l}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		for {
+			in, err := l.Accept()
+			if err != nil {
+				return
+			}
+			out, err := net.Dial("tcp", target)
+			if err != nil {
+				in.Close()
+				continue
+			}
+
+			n := new(int64)
+			r.m.Lock()
+			r.counts = append(r.counts, n)
+			r.m.Unlock()
+
+			go func() {
+				io.Copy(out, countingReader{in, n})
+				out.Close()
+			}()
+			go func() {
+				io.Copy(in, out)
+				in.Close()
+			}()
+		}
+	}()
+
+	return r
+}
+
+func (r *countingRelay) port() int {
+	return r.listener.Addr().(*net.TCPAddr).Port
+}
+
+func (r *countingRelay) snapshot() []int64 {
+	r.m.Lock()
+	defer r.m.Unlock()
+	s := make([]int64, len(r.counts))
+	for i, n := range r.counts {
+		s[i] = atomic.LoadInt64(n)
+	}
+	return s
+}
+
+type countingReader struct {
+	r io.Reader
+	n *int64
+}
+
+func (c countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	atomic.AddInt64(c.n, int64(n))
+	return n, err
+}
+
+func TestPoolHealthCheckPingsEveryIdleConnection(t *testing.T) {
+	s := mcrcontest.NewServer("secret")
+	defer s.Close()
+	s.HandleResponse("list", "There are 0 of a max of 20 players online: ")
+
+	relay := newCountingRelay(t, s.Addr)
+	p := mcrcon.NewPool("127.0.0.1", relay.port(), "secret", mcrcon.PoolConfig{
+		Size:                3,
+		HealthCheckInterval: 50 * time.Millisecond,
+	})
+	defer p.Close()
+
+	// Slow replies make three commands at once need three connections.
+	s.SetLatency(100 * time.Millisecond)
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := p.Do(context.Background(), "list"); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	s.SetLatency(0)
+
+	before := relay.snapshot()
+	if len(before) != 3 {
+		t.Fatalf("pool opened %d connections, want 3", len(before))
+	}
+
+	time.Sleep(300 * time.Millisecond)
+
+	after := relay.snapshot()
+	for i := range before {
+		if after[i] == before[i] {
+			t.Errorf("connection %d was never pinged while idle", i)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package mcrcon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"time"
+)
+
+// ConnState describes what an MCRCONClient's connection is doing, as reported to the OnStateChange hook.
+type ConnState int
+
+const (
+	StateConnecting ConnState = iota
+	StateConnected
+	StateDisconnected
+	StateAuthFailed
+)
+
+func (s ConnState) String() string {
+	switch s {
+	case StateConnecting:
+		return "connecting"
+	case StateConnected:
+		return "connected"
+	case StateDisconnected:
+		return "disconnected"
+	case StateAuthFailed:
+		return "auth-failed"
+	}
+	return fmt.Sprintf("ConnState(%d)", int(s))
+}
+
+// ReconnectPolicy controls how an MCRCONClient recovers when its connection breaks, for example because the Minecraft
+// server restarted. The first redial happens straight away, and each one after that waits exponentially longer.
+//
+// With MaxAttempts 0 a command can wait for as long as the server is down, which is forever for SendCommand, since its
+// context never ends. Use SendCommandContext with a deadline, or Close the client, to stop it waiting.
+type ReconnectPolicy struct {
+	MaxAttempts    int           // Give up after this many redials. 0 means keep trying until the context is done.
+	InitialBackoff time.Duration // Wait before the second attempt
+	MaxBackoff     time.Duration // Upper limit on the wait between attempts. 0 means no limit.
+	Multiplier     float64       // Growth of the wait per attempt. Anything below 1 is treated as 2.
+	Jitter         float64       // Randomize each wait by up to this fraction of it, e.g. 0.2 for +/-20%
+
+	// Replayable reports whether a command which may already have reached the server is safe to run a second time.
+	// Commands that never made it onto the wire are always retried. If nil, nothing that might have run is replayed.
+	Replayable func(command string) bool
+}
+
+// DefaultReconnectPolicy returns a policy suitable for long-running processes: five attempts over roughly half a minute,
+// replaying only commands that ReplayQueries considers safe.
+func DefaultReconnectPolicy() *ReconnectPolicy {
+	return &ReconnectPolicy{
+		MaxAttempts:    5,
+		InitialBackoff: time.Second,
+		MaxBackoff:     15 * time.Second,
+		Multiplier:     2,
+		Jitter:         0.2,
+		Replayable:     ReplayQueries,
+	}
+}
+
+// readOnlyCommands are commands that only report on the server, so running them twice does no harm.
+var readOnlyCommands = []string{
+	"list",
+	"help",
+	"seed",
+	"banlist",
+	"whitelist list",
+	"time query",
+	"data get",
+	"scoreboard players list",
+	"scoreboard objectives list",
+	"team list",
+	"datapack list",
+}
+
+// ReplayQueries is a ReconnectPolicy.Replayable func that allows commands which only read server state.
+func ReplayQueries(command string) bool {
+	command = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(command), "/"))
+	for _, ro := range readOnlyCommands {
+		if command == ro || strings.HasPrefix(command, ro+" ") {
+			return true
+		}
+	}
+	return false
+}
+
+func (p *ReconnectPolicy) replayable(command string) bool {
+	return p.Replayable != nil && p.Replayable(command)
+}
+
+// backoff works out how long to wait after the given (zero based) failed attempt.
+func (p *ReconnectPolicy) backoff(attempt int) time.Duration {
+	mult := p.Multiplier
+	if mult < 1 {
+		mult = 2
+	}
+
+	d := float64(p.InitialBackoff) * math.Pow(mult, float64(attempt))
+	if p.MaxBackoff > 0 && d > float64(p.MaxBackoff) {
+		d = float64(p.MaxBackoff)
+	}
+	if p.Jitter > 0 {
+		d += d * p.Jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(d)
+}
+
+// SetReconnectPolicy turns on automatic reconnection using p, or turns it off if p is nil. With a policy set, a command
+// that fails because the connection broke causes the client to redial and log in again with the original password, and
+// then to run the command again if that's safe. Commands sent while disconnected also trigger a reconnect.
+func (client *MCRCONClient) SetReconnectPolicy(p *ReconnectPolicy) {
+	client.m.Lock()
+	client.reconnect = p
+	client.m.Unlock()
+}
+
+// OnStateChange registers fn to be called whenever the client's connection state changes. fn is called with the client
+// locked, so it must not call back into the client. Passing nil removes the hook.
+func (client *MCRCONClient) OnStateChange(fn func(ConnState)) {
+	client.m.Lock()
+	client.onState = fn
+	client.m.Unlock()
+}
+
+func (client *MCRCONClient) setState(s ConnState) {
+	if client.onState != nil {
+		client.onState(s)
+	}
+}
+
+// redial re-establishes the connection according to the reconnect policy. Must be called with the client locked. It
+// gives up with ErrClosed as soon as Close is called, since Close is kept waiting for the lock in the meantime.
+func (client *MCRCONClient) redial(ctx context.Context) error {
+	p := client.reconnect
+	var err error
+
+	// Close interrupts a dial or login that's under way, as well as the waits between attempts.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-client.closing:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for attempt := 0; p.MaxAttempts == 0 || attempt < p.MaxAttempts; attempt++ {
+		if attempt > 0 {
+			t := time.NewTimer(p.backoff(attempt - 1))
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				if client.isClosed() {
+					return ErrClosed
+				}
+				return &TimeoutError{Op: "reconnect", Err: ctx.Err()}
+			case <-t.C:
+			}
+		}
+
+		client.setState(StateConnecting)
+		if err = client.connect(ctx); err == nil {
+			if client.isClosed() {
+				client.Connected = false
+				client.conn.Close()
+				client.setState(StateDisconnected)
+				return ErrClosed
+			}
+			return nil
+		}
+
+		// Trying the same password again isn't going to help.
+		if errors.Is(err, ErrAuthFailed) {
+			client.setState(StateAuthFailed)
+			return err
+		}
+		client.setState(StateDisconnected)
+
+		if client.isClosed() {
+			return ErrClosed
+		}
+		if ctx.Err() != nil {
+			return &TimeoutError{Op: "reconnect", Err: ctx.Err()}
+		}
+	}
+
+	return fmt.Errorf("Could not reconnect to RCON server at %s:%d after %d attempts. (Error was: %w)", client.addr, client.port, p.MaxAttempts, err)
+}
@@ -5,7 +5,26 @@ import (
 	"net/http"
 )
 
-// Handle a request tho the root reesource
+// apiEndpoint describes one of the API's routes, for the index at /api.
+type apiEndpoint struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Description string `json:"description"`
+}
+
+var apiEndpoints = []apiEndpoint{
+	{"GET", "/api/users", "Who's online"},
+	{"GET", "/api/users/:username", "Everything the server can tell us about a player"},
+	{"GET", "/api/query", "The server's Query protocol full stat"},
+	{"GET", "/api/status", "The server's Server List Ping status"},
+	{"POST", "/api/commands", "Run a command, or a batch of them"},
+	{"GET", "/api/console", "WebSocket streaming the server's console and running commands"},
+}
+
+// Handle a request tho the root reesource, with an index of the API
 func apiRootHandler(w http.ResponseWriter, r *http.Request) {
-	w.Write([]byte("JSON DATA"))
+	writeJSON(w, http.StatusOK, struct {
+		Name      string        `json:"name"`
+		Endpoints []apiEndpoint `json:"endpoints"`
+	}{"minecontrol", apiEndpoints})
 }
@@ -0,0 +1,120 @@
+// Authentication for the REST server
+
+package restServer
+
+import (
+	"context"
+	"crypto/subtle"
+	"golang.org/x/crypto/bcrypt"
+	"net/http"
+	"strings"
+)
+
+// Identity is who a request was authenticated as.
+type Identity struct {
+	Name  string // The username, or the token's name
+	Token string // The token's ID, if a token was used
+}
+
+type identityKey struct{}
+
+// requestIdentity returns who made the request, or nil if authentication is turned off.
+func requestIdentity(r *http.Request) *Identity {
+	id, _ := r.Context().Value(identityKey{}).(*Identity)
+	return id
+}
+
+// authenticator checks requests against the configured users and the API tokens.
+type authenticator struct {
+	// users maps lowercased usernames to their passwords, either as is or bcrypt hashed.
+	users     map[string]string
+	tokens    *TokenStore
+	publicGUI bool
+
+	// required is whether requests have to authenticate at all. It's decided once, when the server starts, by whether
+	// there was anyone to authenticate; revoking the last token or losing the token file mustn't open the API up.
+	required bool
+}
+
+// newAuthenticator sets up authentication for users and tokens. It's only required if at least one of them is set up.
+func newAuthenticator(users map[string]string, tokens *TokenStore, publicGUI bool) *authenticator {
+	return &authenticator{
+		users:     users,
+		tokens:    tokens,
+		publicGUI: publicGUI,
+		required:  len(users) > 0 || !tokens.Empty(),
+	}
+}
+
+// dummyHash is checked against when there's no such user, so the response takes as long as for a wrong password and
+// doesn't give away which usernames exist.
+var dummyHash = []byte("$2a$10$EKQ3fpN5tM6SPriHMksII.GJJbDZVbEfTEZYfoueTzqKTZHy0l0va")
+
+// public reports whether a path can be requested without authenticating.
+func (a *authenticator) public(path string) bool {
+	if path == "/" {
+		return true
+	}
+	return a.publicGUI && strings.HasPrefix(path, "/gui/")
+}
+
+// middleware requires requests to carry a valid username and password, or an "Authorization: Bearer" API token.
+// Who the request came from is put in its context, see requestIdentity.
+func (a *authenticator) middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.public(r.URL.Path) || !a.required {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		id := a.authenticate(r)
+		if id == nil {
+			w.Header().Set("WWW-Authenticate", `Basic realm="minecontrol", charset="UTF-8"`)
+			writeProblem(w, newProblem(http.StatusUnauthorized, codeUnauthorized, "Log in with a username and password, or an API token."))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), identityKey{}, id)))
+	})
+}
+
+// authenticate returns who the request is from, or nil if its credentials aren't valid.
+func (a *authenticator) authenticate(r *http.Request) *Identity {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		info, ok := a.tokens.Check(strings.TrimSpace(strings.TrimPrefix(auth, "Bearer ")))
+		if !ok {
+			return nil
+		}
+		return &Identity{Name: info.Name, Token: info.ID}
+	}
+
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return nil
+	}
+
+	stored, known := a.users[strings.ToLower(username)]
+	if !checkPassword(stored, password, known) {
+		return nil
+	}
+	return &Identity{Name: username}
+}
+
+// checkPassword compares a password with one from the config, which may be a bcrypt hash. known says whether there was
+// a password to compare with; if not, the time a bcrypt comparison takes is spent anyway, so an unknown username isn't
+// any quicker to reject than a known one with a hashed password.
+func checkPassword(stored, password string, known bool) bool {
+	if !known {
+		bcrypt.CompareHashAndPassword(dummyHash, []byte(password))
+		return false
+	}
+
+	if isBcryptHash(stored) {
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(password)) == nil
+	}
+	return subtle.ConstantTimeCompare([]byte(stored), []byte(password)) == 1
+}
+
+func isBcryptHash(s string) bool {
+	return strings.HasPrefix(s, "$2a$") || strings.HasPrefix(s, "$2b$") || strings.HasPrefix(s, "$2y$")
+}
@@ -0,0 +1,87 @@
+package restServer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// authedStatus makes a request for /api/status through a's middleware and returns the response code.
+func authedStatus(a *authenticator, token string) int {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	req := httptest.NewRequest("GET", "/api/status", nil)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	a.middleware(ok).ServeHTTP(rec, req)
+	return rec.Code
+}
+
+func TestRevokingLastTokenKeepsAuthRequired(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tokens.json")
+	tokens, err := LoadTokenStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, info, err := tokens.Create("ci")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := newAuthenticator(map[string]string{}, tokens, false)
+	if !a.required {
+		t.Fatal("authentication not required with a token set up")
+	}
+
+	if code := authedStatus(a, token); code != http.StatusOK {
+		t.Errorf("valid token: got %d, want 200", code)
+	}
+	if code := authedStatus(a, ""); code != http.StatusUnauthorized {
+		t.Errorf("no token: got %d, want 401", code)
+	}
+
+	if err := tokens.Revoke(info.ID); err != nil {
+		t.Fatal(err)
+	}
+	if code := authedStatus(a, token); code != http.StatusUnauthorized {
+		t.Errorf("revoked last token: got %d, want 401", code)
+	}
+	if code := authedStatus(a, ""); code != http.StatusUnauthorized {
+		t.Errorf("no token after revoking the last one: got %d, want 401", code)
+	}
+
+	// Nor does losing the file open things up.
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if code := authedStatus(a, ""); code != http.StatusUnauthorized {
+		t.Errorf("no token after deleting the file: got %d, want 401", code)
+	}
+}
+
+func TestNoCredentialsConfigured(t *testing.T) {
+	tokens, err := LoadTokenStore(filepath.Join(t.TempDir(), "tokens.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	a := newAuthenticator(map[string]string{}, tokens, false)
+	if a.required {
+		t.Fatal("authentication required with nobody to authenticate")
+	}
+	if code := authedStatus(a, ""); code != http.StatusOK {
+		t.Errorf("got %d, want 200", code)
+	}
+
+	// A token created while the server is running doesn't change that until it's restarted.
+	if _, _, err := tokens.Create("late"); err != nil {
+		t.Fatal(err)
+	}
+	if code := authedStatus(a, ""); code != http.StatusOK {
+		t.Errorf("after creating a token: got %d, want 200", code)
+	}
+}
@@ -1,5 +1,7 @@
 // Code generated by go-bindata.
 // sources:
+// gui/assets/console.html
+// gui/assets/console.js
 // gui/assets/index.html
 // gui/assets/stylesheet.css
 // DO NOT EDIT!
@@ -70,7 +72,47 @@ func (fi bindataFileInfo) Sys() interface{} {
 	return nil
 }
 
-var _indexHtml = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\xb2\xc9\x28\xc9\xcd\xb1\xe3\x52\x50\xb0\xc9\x48\x4d\x4c\x01\x31\x80\xcc\x92\xcc\x92\x9c\x54\x3b\xdf\xcc\xbc\xd4\xe4\xfc\xbc\x92\xa2\xfc\x1c\x1b\x7d\x88\x10\x44\x3a\x27\x33\x2f\x5b\x21\xa3\x28\x35\xcd\x56\xa9\xb8\xa4\x32\x27\xb5\x38\x23\x35\xb5\x44\x2f\xb9\xb8\x58\x49\xa1\x28\x35\x07\x59\x50\x49\x41\x1f\x6c\xb6\x3e\xcc\x70\x9b\xa4\xfc\x94\x4a\x88\x31\x21\x19\x99\xc5\x0a\x40\x94\x8b\xb0\x46\x0f\xac\x16\xa2\x04\xa8\x07\xec\x32\x40\x00\x00\x00\xff\xff\xe1\xee\xc8\xae\xa1\x00\x00\x00")
+var _consoleHtml = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x4d\x90\x41\x8e\xc3\x20\x0c\x45\xf7\x73\x0a\xc4\xa2\xcb\x72\x80\x92\x6c\x66\x3d\x87\xa0\xc4\x0c\x4c\x0d\x58\xd8\x91\x9a\xdb\x0f\x09\x69\xd5\xdd\xd7\x7f\x96\xfd\xbf\x6d\x94\x8c\xf3\x97\x52\x36\x82\x5b\x76\xd1\xa5\x24\x41\x98\x7f\x52\x01\x5f\x8b\xb4\x8a\xea\xbb\x16\xae\x08\xd6\x0c\x34\xc6\x30\x95\x87\x8a\x0d\xc2\xa4\x59\x36\x04\x8e\x00\x72\xf5\xcc\x5a\x35\xc0\x4f\x53\x2b\x73\xdc\x30\xaf\x23\xf6\x5e\x97\xed\x5c\x43\x0d\x54\x5a\x26\xed\xc7\x0d\x3d\x5b\xd3\xad\x13\x86\xda\xf2\x41\xa9\xd5\x4c\xa2\x87\xdd\x01\x93\x2b\xf3\xe5\x57\x6e\x97\x72\x67\xba\x59\x73\x18\x2f\x9a\x0a\xad\x72\x6e\xcd\xd9\x95\x45\x2b\xd9\x08\x26\x2d\xf0\xec\x69\xdc\x2a\xb5\x03\x42\x90\xee\xd5\x10\xb4\x62\x02\x44\x1f\xc1\x3f\x26\x1d\x1c\x32\x8c\xa9\x50\xfd\xca\x23\xfd\x9e\x7f\x8f\x73\x6a\xf6\x2d\x91\x28\x6e\xfe\x1d\xfd\xfa\xc7\x7b\xfa\x41\x46\xe1\xd1\xb3\x17\x3f\xde\xfc\x0f\x84\x64\x3b\xa8\x6e\x01\x00\x00")
+
+func consoleHtmlBytes() ([]byte, error) {
+	return bindataRead(
+		_consoleHtml,
+		"console.html",
+	)
+}
+
+func consoleHtml() (*asset, error) {
+	bytes, err := consoleHtmlBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "console.html", size: 366, mode: os.FileMode(420), modTime: time.Unix(1792207160, 0)}
+	a := &asset{bytes: bytes, info:  info}
+	return a, nil
+}
+
+var _consoleJs = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x95\x56\x4b\x6f\xdc\x36\x10\xbe\xfb\x57\x4c\x75\x48\x24\xec\x5a\xeb\x1c\x72\x59\xd7\x0e\x1a\xdb\x40\xd3\x06\x4e\x50\x3b\x28\x8a\xc0\x07\x5a\x9a\x95\x58\x53\xa4\x40\x52\xde\x2e\x92\xfd\xef\x1d\x3e\xb4\xd2\xbe\x9c\xd6\x87\x35\x45\x0e\xe7\xf1\xcd\x37\x33\x9c\xcd\xe0\xbe\x46\x28\x94\x34\x4a\x20\xb4\xac\xc2\x39\x58\xda\x31\xa8\x9f\x51\xbf\x36\x20\x54\x05\xcc\x00\xb7\xb4\x5e\x6a\x6e\x2d\xca\x29\x30\x59\x02\x83\x56\xab\xa6\xb5\xb0\x50\x1a\x74\x27\x25\x97\x15\x29\x6a\x1a\x3a\x34\x53\x50\x74\x1d\x66\xac\xe5\xb3\xa8\x3c\x3f\x39\x49\x17\x9d\x2c\x2c\x57\x12\xd2\x0c\xbe\x9d\x00\x3c\x33\x0d\xaa\xb3\x6d\x67\xe1\x02\x4a\x55\x74\x0d\x4a\x9b\x57\x68\x6f\x04\xba\xe5\xfb\xd5\x87\x32\x4d\xa2\x82\x24\x3b\x8f\x57\xa2\xe1\x17\xae\x04\x89\xe1\x06\x97\x3f\xb4\xe1\x1d\x77\x37\xe2\x15\xa3\x8a\x27\x74\x77\x64\x27\x44\xaf\x47\xe2\x3f\xf6\xc3\x35\x6d\xbe\xe9\x77\x6a\x6e\xac\xd2\x2b\xda\xfa\xfa\xb0\xb3\xf7\x59\x19\xda\x3e\xf3\x1a\x67\x33\xf8\x1d\xb1\x25\xb0\x84\x50\x4b\x07\x95\x43\x19\x09\x47\xb5\xf0\xcb\x00\xc3\x14\x3a\x29\xd0\x18\xbf\xd5\x51\x12\xa0\x26\xf0\x4d\xa1\xe9\x1a\x96\xd0\xb5\x60\x15\x68\x64\x25\xb9\xd7\xa0\xad\x49\x51\x4e\xda\x37\xb8\xb6\x9a\x4b\x9b\x5a\xf2\x72\x0a\x85\x60\xc6\xdc\xb2\x06\x03\xd6\xc1\x35\x66\xdf\x2b\x6b\x55\x43\x8e\x91\x17\xa5\x5a\xe6\x5c\x4a\xd4\xbf\x22\xaf\x6a\x0b\x93\x7e\x33\x58\xfc\x0b\x2e\x47\x90\x3d\xaa\x72\x15\x0f\xa2\xf8\x29\xbc\x09\xd1\x05\xdd\x82\x4b\x1c\x63\x5c\x90\xa3\x16\x23\xcc\x69\x52\xf2\xe7\x90\x10\xf0\x92\xb9\xf3\xf2\x4a\x49\x22\x94\x43\xd9\x7d\x85\x43\xbe\x80\x74\xcf\xf7\x78\x67\xb3\x4f\x37\x36\xeb\x70\x6d\xed\x7f\x03\x8c\x39\x6b\x5b\xc2\xf6\xaa\xe6\xa2\x4c\xdd\xc5\x2c\xba\xe9\x74\xf7\x10\x0c\xaa\xb7\x82\xbe\x57\xe9\xd9\xf4\x85\xa8\xb3\xc1\xdc\xfa\x64\x8c\x3d\xd1\x54\x62\x61\xd3\x31\xdc\xa6\xa8\xd1\x3b\x1b\x4d\x08\x55\x30\x27\x9c\x13\x41\xad\x2a\x94\x80\x8b\x8b\x0b\x48\x6a\x6b\x5b\x33\x4f\xe0\x1d\x24\x4b\x63\xe6\xb3\x59\x02\x73\xb7\x74\xab\x60\x6e\x60\x23\x2e\xe1\x4f\x7c\xbc\xf3\xdf\x69\xd4\x3f\xd9\xd3\x5f\x2b\xe3\xd2\x99\x8c\x2b\x30\xe9\x51\x08\xca\x72\x25\x1b\xa2\x1a\x95\x3c\xa9\x1d\x2a\x13\x9f\x29\xec\x01\x1b\x17\x45\x63\x2a\x12\xf9\xed\xee\xd3\x6d\xde\x32\x6d\x30\xc8\xe4\x25\xb3\x2c\xa2\x41\x3a\x97\xdc\x16\x35\xa4\x24\x9b\xdb\x55\x3b\x4a\x1c\x40\xc1\x0c\x52\x90\x48\xd4\x4f\xe6\x9b\x5d\x88\x6c\x4d\xae\x02\x70\xc4\x6f\xe2\x7a\x42\x5e\x3b\x1d\x9e\xfc\x14\x40\x9e\x4c\x21\x91\xca\xf2\x02\x93\x8d\x31\xf7\xf7\x48\xec\x7a\x3a\xdf\xb1\x41\xdd\xea\x80\x05\xa7\x2f\xb2\xe0\x07\xf7\xa3\xa1\xe3\x2a\xfe\x8f\x33\x1a\x4d\x27\xec\x96\x32\xc7\x3f\xa7\x8a\x8e\x5a\x4a\xca\x16\x4a\xdb\xc6\x36\x12\x63\x33\xeb\x03\xaa\x50\x6b\xa5\x8f\xeb\xf1\xc7\x79\x89\x96\x71\x01\xdf\xbf\xc3\xb0\x67\xb9\x15\x2e\x1e\xff\xb5\x1d\xce\x8e\xf6\xdc\x74\x55\x85\xc6\xf1\xc3\xec\x5a\xda\x64\xf1\x9a\x97\xb0\x52\x1d\x34\xc8\xe4\x7c\x93\xc6\xbd\xfb\xf9\xdf\x8a\xcb\xd4\x25\x35\x73\xe9\x7d\x97\x1c\xf3\x60\x7d\x24\xee\x2d\xa8\xc3\xc1\x3a\xf2\x7a\x16\xa6\x59\x98\x5d\x50\x29\xd7\x66\xd9\x92\xad\x20\xa5\x11\xd5\x50\xf6\xa8\x10\x2c\x55\x32\x75\x50\x63\x99\xb6\x74\x9e\x81\xa9\x55\x27\x4a\xf9\xda\x52\x69\x11\x05\x5d\xe3\x75\x63\x90\x64\x84\x62\x65\x6c\xb0\xa3\x9a\x29\x84\x32\xdb\x15\x33\x40\xb2\x81\xc2\x14\x3d\xa7\x73\xf8\x03\xe3\x87\x73\x87\x4b\x78\x4b\x0e\xd2\x4e\x69\xf2\x3c\x3f\x10\xfd\xde\xdc\xf1\x9b\x68\xef\x79\x83\xd4\xdd\xd2\xa8\x6c\x0a\x6f\xcf\xce\xce\xfa\x66\x74\xde\x77\xa3\x30\xf8\x72\x56\x96\x37\xae\x46\x3f\xd2\x24\x42\xea\xef\x69\x62\xba\xc7\x86\x5b\xb2\x77\xa4\xd2\x43\x49\xb7\xda\xff\xbf\xc6\x05\x23\xea\xa6\xd9\xa8\xbb\xc7\x09\x49\x8e\xf9\x61\x9a\x3f\x33\xd1\x51\x13\xd7\xbc\x49\xb3\x51\xe3\xee\xa5\x5c\x5b\x4b\x06\x64\x34\xda\x4e\xcb\x71\xa7\x1e\x69\x01\x27\x3b\xea\xd0\x71\x7e\x7e\x8d\xff\x73\x81\xb2\xb2\xb5\x1b\x37\x0f\xf0\x13\x29\x8e\x46\x06\xed\xbd\x60\xdb\x99\xba\x77\x21\x1b\x1b\xdb\x9a\xc8\xdb\x6a\xa3\xdd\x98\xba\x4b\xcf\xdc\xa8\x82\x92\x33\x7a\x17\xf4\xde\xf5\x09\xba\x08\x29\x72\x45\x15\xc9\xe1\x06\xf3\xea\xce\xd2\xd0\xf3\x6e\x6e\xda\x74\xfe\xe9\xf3\xcd\xed\x1e\x4b\x6e\x95\x85\x81\x26\x07\x98\xb0\x8f\x59\xb4\x63\x68\xb6\xa5\xbe\x1d\x1b\xc2\x5f\x56\x7c\xb1\x4a\xbf\xb9\xa6\x3b\x1f\x1c\x9e\x02\x2f\xe7\x70\xe7\xcf\xd3\xf0\x6c\x99\x4c\xb2\x69\x1f\xda\xbc\x5f\xac\x33\x6f\x6f\x9d\xf5\xcf\x94\x2f\xad\x7f\xde\xd1\x34\x91\x54\x42\xf0\xc8\x8a\x27\xbf\x41\xcf\x3c\xca\x81\xad\xb5\xea\xaa\xda\x97\x49\xff\xd8\x03\x26\x7c\xe4\xee\x15\x38\xa5\x39\xfd\x84\xf4\x38\xb4\xa8\xa9\xe2\x98\x70\xd5\x13\x52\xbd\xcf\xc9\x27\x5c\x39\x33\xc7\x49\xe9\xe0\x0e\xc4\x24\xd1\xc0\xa9\x5f\x08\xa3\xe5\x97\x36\x81\x57\xaf\xc6\x59\xbd\x84\xb3\x3d\x3a\xd0\xfe\xe9\x69\x0f\xe6\x36\xdd\x76\x18\x46\x92\x0f\xbd\xe0\xb1\x42\xf0\x59\x00\x14\x54\xfc\xc7\xdc\xba\x76\xc1\xec\x38\xf6\xf3\x0e\xdd\x0e\x79\x39\x99\xbc\xe8\xe5\x21\x35\xf4\x54\x38\x10\x83\x7b\x35\x24\xff\x29\x90\x21\xe5\x9b\x67\xcb\xf9\xc9\x3a\x73\xbf\xff\x02\x63\x08\x29\x0c\x14\x0c\x00\x00")
+
+func consoleJsBytes() ([]byte, error) {
+	return bindataRead(
+		_consoleJs,
+		"console.js",
+	)
+}
+
+func consoleJs() (*asset, error) {
+	bytes, err := consoleJsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "console.js", size: 3092, mode: os.FileMode(420), modTime: time.Unix(1792207160, 0)}
+	a := &asset{bytes: bytes, info:  info}
+	return a, nil
+}
+
+var _indexHtml = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x4d\x8f\x31\x0e\xc2\x30\x0c\x45\x77\x4e\x61\xe5\x00\xc9\x05\x4c\x16\x66\x36\x2e\x10\x5a\xa3\x44\xb8\x49\x15\x7b\xe9\xed\x49\xeb\x22\x90\x3c\x7c\xff\x2f\xbd\x6f\x63\xd6\x85\xe3\x05\x00\x33\xa5\x79\x17\x43\x6a\x51\xa6\x78\x2f\x95\xa6\x56\xb5\x37\xc6\x60\x96\xc5\x5c\xea\x1b\x72\xa7\xd7\xd5\x89\x6e\x4c\x92\x89\xd4\x4f\x22\x0e\x3a\xf1\xbf\xe9\x20\x1c\xec\xf0\x85\xe3\xb3\xcd\x9b\x61\x1e\xb9\x08\x8c\x59\x7e\x35\xde\xf8\x6b\xc4\x74\xf2\x47\x20\x8d\xc9\xef\x57\xba\x78\xb3\x0d\x43\x8a\x18\x56\x23\x1b\x70\x34\x1c\x7f\x7c\x00\xa3\xcf\x51\xcb\xcf\x00\x00\x00")
 
 func indexHtmlBytes() ([]byte, error) {
 	return bindataRead(
@@ -85,12 +127,12 @@ func indexHtml() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "index.html", size: 161, mode: os.FileMode(420), modTime: time.Unix(1444505558, 0)}
+	info := bindataFileInfo{name: "index.html", size: 207, mode: os.FileMode(420), modTime: time.Unix(1792207160, 0)}
 	a := &asset{bytes: bytes, info:  info}
 	return a, nil
 }
 
-var _stylesheetCss = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x4a\xca\x4f\xa9\x54\xa8\xe6\x52\x50\x48\xce\xcf\xc9\x2f\xb2\x52\x28\xcf\xc8\x2c\x49\xb5\x06\xf2\x93\x12\x93\xb3\xd3\x8b\xf2\x4b\xf3\x52\xac\x14\x92\x72\x80\x1c\x6b\xae\x5a\x2e\x40\x00\x00\x00\xff\xff\xdd\x75\x78\xc6\x2e\x00\x00\x00")
+var _stylesheetCss = []byte("\x1f\x8b\x08\x00\x00\x09\x6e\x88\x00\xff\x8d\x52\xd1\x6e\xc3\x20\x0c\x7c\xcf\x57\x20\xf5\x39\xd5\x56\x29\x2f\xe4\x6b\x48\x30\xa9\x55\xb0\x91\x43\xd4\x56\x53\xff\x7d\x40\x9b\x69\x89\xa6\x6a\x7e\xf2\x71\xa7\xe3\x6c\x18\xd8\xde\xd5\x57\xa3\xd4\xc8\x9e\x45\xab\xeb\x19\x13\xf4\x19\x0f\x66\xbc\x4c\xc2\x0b\x59\xad\x06\x9f\x41\xdf\x3c\x9a\xc6\xfc\xd6\x1e\xba\x5c\xce\x55\xe2\x30\x32\xcd\xec\xa1\xf2\x8e\x29\xb5\xce\x04\xf4\x77\xad\x02\x13\xcf\xd1\x8c\xd5\xb5\xda\xb7\x15\x6a\x15\x05\xda\xab\x98\x58\x09\x16\x5b\x41\xbe\x4d\xc0\x5c\xda\x72\x50\x88\x60\x64\x42\xd2\xea\xa3\x80\x68\xac\x45\x9a\xda\x81\x53\xe2\xa0\xd5\x09\xc2\xf6\xf6\xe3\xc8\x21\x18\xb2\x9b\x98\x2e\x57\xd7\xed\x84\x20\xc2\xb2\x93\x95\x79\x76\x32\xe2\x84\x23\x6c\x74\xa6\xd6\x53\x17\x85\x43\x4c\x95\x8e\x3c\x63\x42\xce\x51\x1d\xde\xa0\x66\x5f\x63\xd6\xec\x1e\x5c\x7a\xb5\x82\xd3\x79\xed\x2d\xce\xd1\x9b\xbc\x27\xe7\xe1\xd6\xbf\x5d\xde\x1f\x4f\xf2\xb3\x92\xec\x76\x3c\x75\xeb\x3a\x5e\xb1\x90\xe2\xf2\x0c\x57\xcc\xb5\xfa\x7c\xef\xff\x8f\x2f\x50\x66\x12\x0b\x59\x45\x4c\x55\xc4\x4b\xf2\x48\xb0\x1e\x3c\x9a\x6f\xca\x9a\x6a\x31\x52\x02\x00\x00")
 
 func stylesheetCssBytes() ([]byte, error) {
 	return bindataRead(
@@ -105,7 +147,7 @@ func stylesheetCss() (*asset, error) {
 		return nil, err
 	}
 
-	info := bindataFileInfo{name: "stylesheet.css", size: 46, mode: os.FileMode(420), modTime: time.Unix(1444662600, 0)}
+	info := bindataFileInfo{name: "stylesheet.css", size: 594, mode: os.FileMode(420), modTime: time.Unix(1792207160, 0)}
 	a := &asset{bytes: bytes, info:  info}
 	return a, nil
 }
@@ -162,6 +204,8 @@ func AssetNames() []string {
 
 // _bindata is a table, holding each asset generator, mapped to its name.
 var _bindata = map[string]func() (*asset, error){
+	"console.html": consoleHtml,
+	"console.js": consoleJs,
 	"index.html": indexHtml,
 	"stylesheet.css": stylesheetCss,
 }
@@ -206,6 +250,10 @@ type bintree struct {
 	Children map[string]*bintree
 }
 var _bintree = &bintree{nil, map[string]*bintree{
+	"console.html": &bintree{consoleHtml, map[string]*bintree{
+	}},
+	"console.js": &bintree{consoleJs, map[string]*bintree{
+	}},
 	"index.html": &bintree{indexHtml, map[string]*bintree{
 	}},
 	"stylesheet.css": &bintree{stylesheetCss, map[string]*bintree{
@@ -0,0 +1,173 @@
+// Handle the /api/commands route
+
+package restServer
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/joshproehl/minecontrol/formatting"
+	"github.com/joshproehl/minecontrol/mcrcon"
+	"github.com/joshproehl/minecontrol/mcrcon/cmdtree"
+	rcmd "github.com/joshproehl/minecontrol/mcrcon/commands"
+	jww "github.com/spf13/jwalterweatherman"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// maxBatch is the most commands one request can run.
+	maxBatch = 100
+
+	// commandTimeout is how long each command gets before we give up waiting for the response.
+	commandTimeout = 30 * time.Second
+)
+
+// commandRequest is the body of a POST to /api/commands: either a single command, or a batch run in order.
+type commandRequest struct {
+	Command     string   `json:"command"`
+	Commands    []string `json:"commands"`
+	Format      string   `json:"format"`
+	StopOnError bool     `json:"stopOnError"`
+}
+
+// commandResult is the outcome of one command.
+type commandResult struct {
+	Command    string   `json:"command"`
+	Response   string   `json:"response"`
+	DurationMS float64  `json:"durationMs"`
+	Error      *problem `json:"error,omitempty"` // Why the command failed, if it did
+}
+
+// commandResponse is the response to a POST to /api/commands.
+type commandResponse struct {
+	RequestID string `json:"requestId"`
+
+	// For a single command
+	*commandResult
+
+	// For a batch
+	Results []commandResult `json:"results,omitempty"`
+	Stopped bool            `json:"stopped,omitempty"` // stopOnError cut the batch short
+
+	// How long the whole request took. A single command's result has its own durationMs, for the command alone.
+	TotalMS float64 `json:"totalMs"`
+}
+
+// Handle a request to run commands. A single command that can't be run gets a problem response, while the commands in
+// a batch each get a result, with any error in it, so one failure doesn't hide the outcome of the others.
+func commandsHandler(w http.ResponseWriter, r *http.Request) {
+	requestID := newRequestID()
+	w.Header().Set("X-Request-ID", requestID)
+
+	var req commandRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		writeProblem(w, newProblem(http.StatusBadRequest, codeInvalidRequest, fmt.Sprintf("Could not read request: %s", err)))
+		return
+	}
+
+	format := formatting.Plain
+	if req.Format != "" {
+		var err error
+		if format, err = formatting.ParseFormat(req.Format); err != nil {
+			writeProblem(w, newProblem(http.StatusBadRequest, codeInvalidRequest, err.Error()))
+			return
+		}
+	}
+
+	batch := req.Commands != nil
+	switch {
+	case batch && req.Command != "":
+		writeProblem(w, newProblem(http.StatusBadRequest, codeInvalidRequest, "Give either command or commands, not both."))
+		return
+	case !batch && strings.TrimSpace(req.Command) == "":
+		writeProblem(w, newProblem(http.StatusBadRequest, codeInvalidRequest, "No command given."))
+		return
+	case len(req.Commands) > maxBatch:
+		writeProblem(w, newProblem(http.StatusBadRequest, codeInvalidRequest, fmt.Sprintf("At most %d commands can be run at once.", maxBatch)))
+		return
+	}
+
+	c := commanderFor(r)
+	who := "anonymous"
+	if id := requestIdentity(r); id != nil {
+		who = id.Name
+	}
+
+	start := time.Now()
+	resp := commandResponse{RequestID: requestID}
+
+	if !batch {
+		result := runAPICommand(r.Context(), c, req.Command, format)
+		jww.INFO.Println(fmt.Sprintf("[%s] %s ran %q", requestID, who, req.Command))
+
+		// Errors that stopped the command running at all are the response; the server rejecting it is just part of
+		// the result.
+		if result.Error != nil && result.Error.Code != codeCommandFailed {
+			writeProblem(w, result.Error)
+			return
+		}
+		resp.commandResult = &result
+	} else {
+		resp.Results = []commandResult{}
+		for _, command := range req.Commands {
+			result := runAPICommand(r.Context(), c, command, format)
+			jww.INFO.Println(fmt.Sprintf("[%s] %s ran %q", requestID, who, command))
+			resp.Results = append(resp.Results, result)
+
+			if result.Error != nil && req.StopOnError {
+				resp.Stopped = len(resp.Results) < len(req.Commands)
+				break
+			}
+		}
+	}
+
+	resp.TotalMS = milliseconds(time.Since(start))
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// runAPICommand checks and runs a single command. Commands starting with cmdtree.Unchecked skip the check against the
+// server's help, for when it's behind the server, though not the policy.
+func runAPICommand(ctx context.Context, c mcrcon.Commander, command string, format formatting.Format) commandResult {
+	result := commandResult{Command: command}
+
+	command, unchecked := cmdtree.StripUnchecked(command)
+	if err := checkCommand(command); err != nil && !unchecked {
+		result.Error = errorProblem(err)
+		return result
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, commandTimeout)
+	defer cancel()
+
+	start := time.Now()
+	response, err := c.SendCommandContext(ctx, command)
+	result.DurationMS = milliseconds(time.Since(start))
+
+	if err != nil {
+		result.Error = errorProblem(err)
+		return result
+	}
+
+	result.Response = formatting.Render(response, format)
+	if err := rcmd.Check(command, response); err != nil {
+		result.Error = errorProblem(err)
+	}
+
+	return result
+}
+
+// newRequestID makes an ID to tie a request's response to its log lines.
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
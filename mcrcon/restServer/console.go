@@ -0,0 +1,166 @@
+// Handle the /api/console route
+
+package restServer
+
+import (
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/joshproehl/minecontrol/formatting"
+	jww "github.com/spf13/jwalterweatherman"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// consolePing is how often an idle console session is pinged, so dead connections are noticed and proxies don't
+	// close live ones.
+	consolePing = 30 * time.Second
+
+	// consoleWriteWait is how long a message to the browser gets before the session is given up on.
+	consoleWriteWait = 10 * time.Second
+)
+
+// console_log follows the server's logs/latest.log for the console. It's nil if we don't know where the server's files are.
+var console_log *logTail
+
+// The GUI is served from the same origin as the API, which is all the upgrader allows by default. That matters, since
+// browsers send Basic auth credentials with a WebSocket request from any page.
+var consoleUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 4096,
+}
+
+// consoleMessage is the one shape of message sent both ways over the console. From the browser, type "command" runs
+// one; to it, "hello" opens the session, "log" is a line of the server's console, "result" is the outcome of a command
+// (with the id it was sent with) and "notice" is anything else worth showing.
+type consoleMessage struct {
+	Type    string `json:"type"`
+	ID      string `json:"id,omitempty"`
+	User    string `json:"user,omitempty"`
+	Line    string `json:"line,omitempty"`
+	Command string `json:"command,omitempty"`
+	*commandResult
+}
+
+// Handle a console session. The server's log is streamed to the browser as it's written, starting with the last few
+// lines, and commands from the browser are run as the authenticated user, so the policy applies just as it does to
+// /api/commands. Responses are rendered according to ?format=.
+func consoleHandler(w http.ResponseWriter, r *http.Request) {
+	format, err := requestedFormat(r)
+	if err != nil {
+		writeProblem(w, newProblem(http.StatusBadRequest, codeInvalidRequest, err.Error()))
+		return
+	}
+
+	// The upgrader writes its own error response if the request isn't a WebSocket handshake.
+	conn, err := consoleUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	who := "anonymous"
+	if id := requestIdentity(r); id != nil {
+		who = id.Name
+	}
+	sessionID := newRequestID()
+	jww.INFO.Println(fmt.Sprintf("[%s] %s opened the console", sessionID, who))
+	defer jww.INFO.Println(fmt.Sprintf("[%s] %s closed the console", sessionID, who))
+
+	if !writeConsole(conn, consoleMessage{Type: "hello", User: who}) {
+		return
+	}
+
+	// Everything else sent to the browser is written here too, since a WebSocket connection only allows one writer
+	// at a time. Results come back from the reader through out.
+	out := make(chan consoleMessage, 16)
+	done := make(chan struct{})
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		defer close(done)
+		readConsole(r, conn, out, stop, sessionID, who, format)
+	}()
+
+	var lines chan string
+	if console_log == nil {
+		if !writeConsole(conn, consoleMessage{Type: "notice", Line: "The server's console isn't available, since minecontrol doesn't know where the server's files are. Commands can still be run."}) {
+			return
+		}
+	} else {
+		var backlog []string
+		lines, backlog = console_log.subscribe()
+		defer console_log.unsubscribe(lines)
+
+		for _, line := range backlog {
+			if !writeConsole(conn, consoleMessage{Type: "log", Line: line}) {
+				return
+			}
+		}
+	}
+
+	ticker := time.NewTicker(consolePing)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case msg := <-out:
+			if !writeConsole(conn, msg) {
+				return
+			}
+		case line := <-lines:
+			if !writeConsole(conn, consoleMessage{Type: "log", Line: line}) {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(consoleWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// readConsole runs the commands sent by the browser, one at a time, until the connection is closed or stop is.
+func readConsole(r *http.Request, conn *websocket.Conn, out chan<- consoleMessage, stop <-chan struct{}, sessionID, who string, format formatting.Format) {
+	conn.SetReadLimit(1 << 16)
+	c := commanderFor(r)
+
+	for {
+		var msg consoleMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			// Not JSON, or the connection went away. Either way there's no carrying on.
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) {
+				jww.DEBUG.Println(fmt.Sprintf("[%s] Console read failed: %s", sessionID, err))
+			}
+			return
+		}
+
+		reply := consoleMessage{Type: "notice", ID: msg.ID, Line: fmt.Sprintf("Unknown message type %q.", msg.Type)}
+		switch {
+		case msg.Type == "command" && strings.TrimSpace(msg.Command) == "":
+			reply.Line = "No command given."
+		case msg.Type == "command":
+			result := runAPICommand(r.Context(), c, msg.Command, format)
+			jww.INFO.Println(fmt.Sprintf("[%s] %s ran %q", sessionID, who, msg.Command))
+
+			// The message's own command field hides the result's, so it has to be set here.
+			reply = consoleMessage{Type: "result", ID: msg.ID, Command: msg.Command, commandResult: &result}
+		}
+
+		select {
+		case out <- reply:
+		case <-stop:
+			return
+		}
+	}
+}
+
+// writeConsole sends a message to the browser, reporting whether the session can carry on.
+func writeConsole(conn *websocket.Conn, msg consoleMessage) bool {
+	conn.SetWriteDeadline(time.Now().Add(consoleWriteWait))
+	return conn.WriteJSON(msg) == nil
+}
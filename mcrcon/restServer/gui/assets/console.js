@@ -0,0 +1,103 @@
+// The console page: the server's log as it's written, and a prompt for running commands, over /api/console.
+
+(function () {
+  var output = document.getElementById("console");
+  var prompt = document.getElementById("prompt");
+  var input = document.getElementById("command");
+
+  var socket = null;
+  var nextID = 1;
+  var history = [];
+  var historyPos = 0;
+
+  // Keep following the end of the output, unless the user has scrolled up to read something.
+  function print(text, className) {
+    var atBottom = window.innerHeight + window.scrollY >= document.body.scrollHeight - 10;
+
+    var line = document.createElement("div");
+    line.textContent = text;
+    if (className) {
+      line.className = className;
+    }
+    output.appendChild(line);
+
+    if (atBottom) {
+      window.scrollTo(0, document.body.scrollHeight);
+    }
+  }
+
+  function connect() {
+    var scheme = window.location.protocol === "https:" ? "wss://" : "ws://";
+    socket = new WebSocket(scheme + window.location.host + "/api/console");
+
+    socket.onmessage = function (event) {
+      var msg = JSON.parse(event.data);
+      switch (msg.type) {
+        case "hello":
+          print("Connected as " + msg.user + ".", "notice");
+          break;
+        case "log":
+          print(msg.line);
+          break;
+        case "notice":
+          print(msg.line, "notice");
+          break;
+        case "result":
+          if (msg.response) {
+            print(msg.response);
+          }
+          if (msg.error) {
+            print(msg.error.detail || msg.error.title, "error");
+            if (msg.error.suggestions) {
+              print("Did you mean: " + msg.error.suggestions.join(", ") + "?", "error");
+            }
+          }
+          break;
+      }
+    };
+
+    // The server going away (or minecontrol restarting) shouldn't need the page reloading.
+    socket.onclose = function () {
+      print("Disconnected. Reconnecting in 5 seconds...", "error");
+      socket = null;
+      setTimeout(connect, 5000);
+    };
+  }
+
+  prompt.addEventListener("submit", function (event) {
+    event.preventDefault();
+
+    var command = input.value.trim();
+    if (command === "") {
+      return;
+    }
+    input.value = "";
+
+    if (history[history.length - 1] !== command) {
+      history.push(command);
+    }
+    historyPos = history.length;
+
+    print("> " + command, "command");
+    if (socket === null || socket.readyState !== WebSocket.OPEN) {
+      print("Not connected.", "error");
+      return;
+    }
+    socket.send(JSON.stringify({type: "command", id: String(nextID++), command: command}));
+  });
+
+  // Up and down go back and forth through the commands already run, like a terminal.
+  input.addEventListener("keydown", function (event) {
+    if (event.key === "ArrowUp" && historyPos > 0) {
+      historyPos--;
+      input.value = history[historyPos];
+      event.preventDefault();
+    } else if (event.key === "ArrowDown" && historyPos < history.length) {
+      historyPos++;
+      input.value = historyPos < history.length ? history[historyPos] : "";
+      event.preventDefault();
+    }
+  });
+
+  connect();
+})();
@@ -0,0 +1,137 @@
+// Following the Minecraft server's log file, for the console
+
+package restServer
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// backlogLines is how much recent log a new console session is sent, so it doesn't start out empty.
+	backlogLines = 100
+
+	// tailInterval is how often the log is checked for new lines.
+	tailInterval = 250 * time.Millisecond
+)
+
+// logTail follows a log file like "tail -F", handing each new line to all its subscribers. It copes with the file being
+// replaced, which the server does with latest.log every time it starts, and with it being truncated.
+type logTail struct {
+	path string
+
+	m       sync.Mutex
+	subs    map[chan string]struct{}
+	backlog []string
+}
+
+func newLogTail(path string) *logTail {
+	return &logTail{path: path, subs: make(map[chan string]struct{})}
+}
+
+// subscribe returns a channel of new lines and the recent lines from before it. Call unsubscribe with the channel once
+// done with it. Lines are dropped rather than wait for a subscriber that isn't keeping up.
+func (t *logTail) subscribe() (chan string, []string) {
+	ch := make(chan string, 256)
+
+	t.m.Lock()
+	defer t.m.Unlock()
+	t.subs[ch] = struct{}{}
+	return ch, append([]string(nil), t.backlog...)
+}
+
+func (t *logTail) unsubscribe(ch chan string) {
+	t.m.Lock()
+	delete(t.subs, ch)
+	t.m.Unlock()
+}
+
+func (t *logTail) publish(line string) {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	t.backlog = append(t.backlog, line)
+	if len(t.backlog) > backlogLines {
+		t.backlog = t.backlog[len(t.backlog)-backlogLines:]
+	}
+
+	for ch := range t.subs {
+		select {
+		case ch <- line:
+		default:
+		}
+	}
+}
+
+// run follows the file until stop is closed, or forever if stop is nil. It starts at the end of the file as it is when
+// run is called; a file that doesn't exist yet is waited for and read from the start.
+func (t *logTail) run(stop <-chan struct{}) {
+	var f *os.File
+	var info os.FileInfo
+	var r *bufio.Reader
+	var partial strings.Builder
+
+	ticker := time.NewTicker(tailInterval)
+	defer ticker.Stop()
+	defer func() {
+		if f != nil {
+			f.Close()
+		}
+	}()
+
+	first := true
+	for {
+		if f == nil {
+			if nf, err := os.Open(t.path); err == nil {
+				f, r = nf, bufio.NewReader(nf)
+				info, _ = f.Stat()
+				partial.Reset()
+				// Only skip what's already there the first time; after a rotation the new file is all new.
+				if first && info != nil {
+					f.Seek(0, io.SeekEnd)
+				}
+			}
+			first = false
+		}
+
+		if f != nil {
+			t.readLines(r, &partial)
+
+			// The file being replaced or truncated means starting again with whatever's at the path now. Anything
+			// written to the old file before it was replaced has just been read.
+			current, err := os.Stat(t.path)
+			pos, _ := f.Seek(0, io.SeekCurrent)
+			if err != nil || !os.SameFile(info, current) || current.Size() < pos-int64(r.Buffered()) {
+				f.Close()
+				f = nil
+				continue
+			}
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// readLines reads whatever complete lines are available. A line still being written is kept in partial until the
+// rest of it turns up.
+func (t *logTail) readLines(r *bufio.Reader, partial *strings.Builder) {
+	for {
+		chunk, err := r.ReadString('\n')
+		partial.WriteString(chunk)
+		if err != nil {
+			return
+		}
+
+		line := strings.TrimRight(partial.String(), "\r\n")
+		partial.Reset()
+		t.publish(line)
+	}
+}
@@ -0,0 +1,280 @@
+// Which commands each user and token is allowed to run
+
+package restServer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/joshproehl/minecontrol/mcrcon"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// ErrForbidden is wrapped by the *PolicyError returned for a command the requester isn't allowed to run.
+var ErrForbidden = errors.New("Command not allowed")
+
+// RoleConfig is a role's rules. Each rule is a command pattern: words that must match the command's, separated by spaces.
+// A word may be
+//
+//	kick        the literal word, ignoring case
+//	add|remove  any one of the literal words
+//	*           any single word
+//	<player>    a player name, but not a selector like @a
+//	<self>      the requester's own name, for users whose usernames match their player names
+//	<selector>  a target selector, like @p or @a[distance=..10]
+//	<number>    a number, like 12, -3.5 or ~2
+//	...         any number of words, including none; it can only come last
+//
+// so "ban <player> ..." allows banning anyone with any reason, but not "ban @a". Rules match commands as they're
+// written, so a rule about a command needs repeating for its aliases, like tp and teleport. The exception is a
+// namespace in front of the command: minecraft:stop is the same as stop, and deny rules also catch the names plugins
+// register commands under, like bukkit:stop or essentials:stop.
+type RoleConfig struct {
+	Allow []string
+	Deny  []string
+}
+
+// PolicyConfig maps users and tokens to roles. With no roles defined, everyone may run anything.
+type PolicyConfig struct {
+	Roles   map[string]RoleConfig
+	Users   map[string][]string // Usernames to roles
+	Tokens  map[string][]string // Token names to roles
+	Default []string            // Roles everyone has, whoever they are
+}
+
+// PolicyError says why a command was refused, and which rule decided it.
+type PolicyError struct {
+	Command string
+	Role    string // The role the deciding rule belongs to, empty if no rule matched
+	Rule    string // The deciding rule, as written in the config, empty if no rule matched
+	Roles   []string
+}
+
+func (e *PolicyError) Error() string {
+	if e.Rule == "" {
+		return fmt.Sprintf("%q isn't allowed by any of your roles (%s).", e.Command, strings.Join(e.Roles, ", "))
+	}
+	return fmt.Sprintf("%q is denied by rule %q of role %q.", e.Command, e.Rule, e.Role)
+}
+
+func (e *PolicyError) Unwrap() error {
+	return ErrForbidden
+}
+
+// policy is a PolicyConfig ready for checking commands against.
+type policy struct {
+	config PolicyConfig
+	roles  map[string]*role
+}
+
+type role struct {
+	allow, deny []*rule
+}
+
+type rule struct {
+	text  string
+	words []ruleWord
+	rest  bool // Ended with "...", so any number of further words match
+}
+
+// ruleWord is a matcher for a single word of a command.
+type ruleWord func(word, self string) bool
+
+var (
+	// A selector like @a, @p[limit=1] or @e[type=minecraft:cow]
+	selectorRe = regexp.MustCompile(`^@[aeprs](\[.*\])?$`)
+
+	// Absolute, relative (~) and local (^) coordinates and plain numbers
+	numberRe = regexp.MustCompile(`^[~^]?(-?\d+(\.\d+)?)?$`)
+)
+
+// newPolicy checks and compiles a PolicyConfig. The error names the rule at fault.
+func newPolicy(c PolicyConfig) (*policy, error) {
+	p := &policy{config: c, roles: make(map[string]*role)}
+
+	// The config file's keys come to us lowercased, so names are compared ignoring case throughout.
+	p.config.Users = lowerKeys(c.Users)
+	p.config.Tokens = lowerKeys(c.Tokens)
+
+	for name, rc := range c.Roles {
+		r := &role{}
+		for _, text := range rc.Allow {
+			ru, err := compileRule(text)
+			if err != nil {
+				return nil, fmt.Errorf("Role %q, rule %q: %s", name, text, err)
+			}
+			r.allow = append(r.allow, ru)
+		}
+		for _, text := range rc.Deny {
+			ru, err := compileRule(text)
+			if err != nil {
+				return nil, fmt.Errorf("Role %q, rule %q: %s", name, text, err)
+			}
+			r.deny = append(r.deny, ru)
+		}
+		p.roles[strings.ToLower(name)] = r
+	}
+
+	for _, name := range c.Default {
+		if p.roles[strings.ToLower(name)] == nil {
+			return nil, fmt.Errorf("Default role %q isn't defined", name)
+		}
+	}
+	for who, names := range c.Users {
+		for _, name := range names {
+			if p.roles[strings.ToLower(name)] == nil {
+				return nil, fmt.Errorf("User %q has role %q, which isn't defined", who, name)
+			}
+		}
+	}
+	for who, names := range c.Tokens {
+		for _, name := range names {
+			if p.roles[strings.ToLower(name)] == nil {
+				return nil, fmt.Errorf("Token %q has role %q, which isn't defined", who, name)
+			}
+		}
+	}
+
+	return p, nil
+}
+
+func compileRule(text string) (*rule, error) {
+	fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(text), "/"))
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("rule is empty")
+	}
+
+	ru := &rule{text: text}
+	for i, f := range fields {
+		switch f {
+		case "...":
+			if i != len(fields)-1 {
+				return nil, fmt.Errorf("... can only come last")
+			}
+			ru.rest = true
+		case "*":
+			ru.words = append(ru.words, func(string, string) bool { return true })
+		case "<player>":
+			ru.words = append(ru.words, func(w, _ string) bool { return mcrcon.ValidPlayerName(w) })
+		case "<self>":
+			ru.words = append(ru.words, func(w, self string) bool { return self != "" && strings.EqualFold(w, self) })
+		case "<selector>":
+			ru.words = append(ru.words, func(w, _ string) bool { return selectorRe.MatchString(w) })
+		case "<number>":
+			ru.words = append(ru.words, func(w, _ string) bool { return w != "" && numberRe.MatchString(w) })
+		default:
+			if strings.HasPrefix(f, "<") {
+				return nil, fmt.Errorf("unknown placeholder %s", f)
+			}
+			alternatives := strings.Split(f, "|")
+			ru.words = append(ru.words, func(w, _ string) bool {
+				for _, a := range alternatives {
+					if strings.EqualFold(w, a) {
+						return true
+					}
+				}
+				return false
+			})
+		}
+	}
+
+	return ru, nil
+}
+
+func (ru *rule) matches(words []string, self string) bool {
+	if len(words) < len(ru.words) || (len(words) > len(ru.words) && !ru.rest) {
+		return false
+	}
+	for i, match := range ru.words {
+		if !match(words[i], self) {
+			return false
+		}
+	}
+	return true
+}
+
+// rolesFor returns the roles id has. A nil id, when authentication is off, only gets the default roles.
+func (p *policy) rolesFor(id *Identity) []string {
+	roles := append([]string(nil), p.config.Default...)
+	if id == nil {
+		return roles
+	}
+	if id.Token != "" {
+		return append(roles, p.config.Tokens[strings.ToLower(id.Name)]...)
+	}
+	return append(roles, p.config.Users[strings.ToLower(id.Name)]...)
+}
+
+func lowerKeys(m map[string][]string) map[string][]string {
+	out := make(map[string][]string, len(m))
+	for k, v := range m {
+		out[strings.ToLower(k)] = v
+	}
+	return out
+}
+
+// check decides whether id may run command. Deny rules win over allow rules, from whichever role, and anything not
+// allowed by some rule is refused.
+func (p *policy) check(id *Identity, command string) error {
+	if p == nil || len(p.roles) == 0 {
+		return nil
+	}
+
+	words := strings.Fields(strings.TrimPrefix(strings.TrimSpace(command), "/"))
+	bare := words
+	if len(words) > 0 {
+		words[0] = strings.TrimPrefix(words[0], "minecraft:")
+		if i := strings.LastIndexByte(words[0], ':'); i >= 0 {
+			bare = append([]string{words[0][i+1:]}, words[1:]...)
+		}
+	}
+
+	self := ""
+	if id != nil && id.Token == "" {
+		self = id.Name
+	}
+
+	roles := p.rolesFor(id)
+	allowed := false
+	for _, name := range roles {
+		r := p.roles[strings.ToLower(name)]
+		if r == nil {
+			continue
+		}
+		for _, ru := range r.deny {
+			if ru.matches(words, self) || ru.matches(bare, self) {
+				return &PolicyError{Command: command, Role: name, Rule: ru.text, Roles: roles}
+			}
+		}
+		for _, ru := range r.allow {
+			if ru.matches(words, self) {
+				allowed = true
+			}
+		}
+	}
+
+	if !allowed {
+		return &PolicyError{Command: command, Roles: roles}
+	}
+	return nil
+}
+
+// policyCommander runs commands through the pool on behalf of a requester, refusing any the policy doesn't let them
+// run. Handlers get one from commanderFor, so nothing reaches the server without being checked.
+type policyCommander struct {
+	id *Identity
+}
+
+func (pc policyCommander) SendCommandContext(ctx context.Context, command string) (string, error) {
+	if err := api_policy.check(pc.id, command); err != nil {
+		return "", err
+	}
+	return rcon_pool.SendCommandContext(ctx, command)
+}
+
+// commanderFor returns what handlers should send commands through for request r.
+func commanderFor(r *http.Request) mcrcon.Commander {
+	return policyCommander{id: requestIdentity(r)}
+}
@@ -0,0 +1,71 @@
+package restServer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPolicyNamespacedCommands(t *testing.T) {
+	p, err := newPolicy(PolicyConfig{
+		Roles: map[string]RoleConfig{
+			"admin": {Allow: []string{"..."}, Deny: []string{"stop", "op ..."}},
+		},
+		Default: []string{"admin"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		command string
+		denied  bool
+	}{
+		{"stop", true},
+		{"/stop", true},
+		{"minecraft:stop", true},
+		{"bukkit:stop", true},
+		{"spigot:stop", true},
+		{"essentials:stop", true},
+		{"/Essentials:STOP", true},
+		{"essentials:op Notch", true},
+		{"a:b:stop", true},
+		{"list", false},
+		{"essentials:list", false},
+		{"stopwatch", false},
+		{"essentials:stopwatch", false},
+		{"say stop", false},
+		{"say bukkit:stop", false},
+	}
+
+	for _, tt := range tests {
+		err := p.check(&Identity{Name: "alice"}, tt.command)
+		if tt.denied != (err != nil) {
+			t.Errorf("check(%q) = %v, want denied %v", tt.command, err, tt.denied)
+			continue
+		}
+		if err != nil && !errors.Is(err, ErrForbidden) {
+			t.Errorf("check(%q) = %v, not ErrForbidden", tt.command, err)
+		}
+	}
+}
+
+func TestPolicyAllowKeepsNamespace(t *testing.T) {
+	p, err := newPolicy(PolicyConfig{
+		Roles:   map[string]RoleConfig{"chat": {Allow: []string{"say ..."}}},
+		Default: []string{"chat"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for command, allowed := range map[string]bool{
+		"say hello":           true,
+		"minecraft:say hello": true,
+		"essentials:say hi":   false,
+		"stop":                false,
+	} {
+		if err := p.check(nil, command); allowed != (err == nil) {
+			t.Errorf("check(%q) = %v, want allowed %v", command, err, allowed)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// JSON error responses for the API
+
+package restServer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/joshproehl/minecontrol/mcrcon"
+	"github.com/joshproehl/minecontrol/mcrcon/cmdtree"
+	rcmd "github.com/joshproehl/minecontrol/mcrcon/commands"
+	jww "github.com/spf13/jwalterweatherman"
+	"net/http"
+	"runtime/debug"
+	"strings"
+)
+
+// Error codes. These are part of the API, so once published they mustn't change meaning.
+const (
+	codeInvalidRequest = "invalid-request"   // The request itself was malformed
+	codeUnauthorized   = "unauthorized"      // No valid username and password or API token
+	codeForbidden      = "forbidden"         // The policy doesn't allow the command
+	codeNotFound       = "not-found"         // No such route, or no such player
+	codeUnknownCommand = "unknown-command"   // The server doesn't have the command, caught before it was sent
+	codeCommandFailed  = "command-failed"    // The server ran the command and reported that it failed
+	codeRCONDown       = "rcon-disconnected" // Couldn't reach the Minecraft server over RCON
+	codeRCONAuthFailed = "rcon-auth-failed"  // The Minecraft server rejected our RCON password
+	codeTimeout        = "rcon-timeout"      // The Minecraft server didn't answer in time
+	codeUpstreamFailed = "upstream-failed"   // The Query or Server List Ping request failed
+	codeInternal       = "internal-error"    // A bug in minecontrol
+)
+
+// problem is an RFC 7807 problem details object, with a code saying what went wrong in a way programs can rely on.
+type problem struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	Code   string `json:"code"`
+
+	// Set for unknown commands, with what might have been meant instead.
+	Suggestions []string `json:"suggestions,omitempty"`
+}
+
+// newProblem makes a problem with the standard title for status.
+func newProblem(status int, code, detail string) *problem {
+	return &problem{Type: "about:blank", Title: http.StatusText(status), Status: status, Detail: detail, Code: code}
+}
+
+// writeProblem sends p as the response.
+func writeProblem(w http.ResponseWriter, p *problem) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(p.Status)
+	json.NewEncoder(w).Encode(p)
+}
+
+// writeJSON sends v as a JSON response. It's encoded before anything is written, so that if encoding fails there's
+// still the chance to send a proper error instead.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		jww.ERROR.Println("Could not encode response:", err)
+		writeProblem(w, newProblem(http.StatusInternalServerError, codeInternal, "Could not encode the response."))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(append(b, '\n'))
+}
+
+// errorProblem describes an error from checking or running a command.
+func errorProblem(err error) *problem {
+	var pe *PolicyError
+	var ue *cmdtree.UnknownError
+	var ce *rcmd.CommandError
+	var te *mcrcon.TimeoutError
+
+	switch {
+	case errors.As(err, &pe):
+		return newProblem(http.StatusForbidden, codeForbidden, pe.Error())
+	case errors.As(err, &ue):
+		p := newProblem(http.StatusUnprocessableEntity, codeUnknownCommand, ue.Error())
+		p.Suggestions = ue.Suggestions
+		return p
+	case errors.As(err, &ce):
+		return newProblem(http.StatusUnprocessableEntity, codeCommandFailed, ce.Response)
+	case errors.As(err, &te):
+		return newProblem(http.StatusGatewayTimeout, codeTimeout, te.Error())
+	case errors.Is(err, mcrcon.ErrPoolExhausted):
+		return newProblem(http.StatusServiceUnavailable, codeTimeout, "All RCON connections are busy, try again shortly.")
+	case errors.Is(err, mcrcon.ErrAuthFailed):
+		return newProblem(http.StatusBadGateway, codeRCONAuthFailed, "The Minecraft server rejected the RCON password.")
+	}
+	return newProblem(http.StatusServiceUnavailable, codeRCONDown, fmt.Sprintf("Could not reach the Minecraft server: %s", err))
+}
+
+// recoverer turns a panic in a handler into a logged 500 response, rather than a dropped connection.
+func recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// This one's net/http's way of saying the response should be abandoned.
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			jww.ERROR.Println(fmt.Sprintf("Panic serving %s %s: %v\n%s", r.Method, r.URL.Path, rec, debug.Stack()))
+			writeProblem(w, newProblem(http.StatusInternalServerError, codeInternal, "Something went wrong, see the server's log."))
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// notFoundHandler answers requests for routes that don't exist, in JSON for the API.
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/api" || strings.HasPrefix(r.URL.Path, "/api/") {
+		writeProblem(w, newProblem(http.StatusNotFound, codeNotFound, fmt.Sprintf("No such resource: %s %s", r.Method, r.URL.Path)))
+		return
+	}
+	http.NotFound(w, r)
+}
@@ -0,0 +1,30 @@
+// Handle the /api/query route
+
+package restServer
+
+import (
+	"fmt"
+	"github.com/joshproehl/minecontrol/formatting"
+	"net/http"
+)
+
+// Handle a request to the /query resource, which reports the server's Query protocol full stat. The MOTD is rendered
+// according to ?format=.
+func queryHandler(w http.ResponseWriter, r *http.Request) {
+	format, err := requestedFormat(r)
+	if err != nil {
+		writeProblem(w, newProblem(http.StatusBadRequest, codeInvalidRequest, err.Error()))
+		return
+	}
+
+	stat, err := query_client.FullStat(r.Context())
+
+	if err != nil {
+		writeProblem(w, newProblem(http.StatusBadGateway, codeUpstreamFailed, fmt.Sprintf("Query failed: %s", err)))
+		return
+	}
+
+	stat.MOTD = formatting.Render(stat.MOTD, format)
+
+	writeJSON(w, http.StatusOK, stat)
+}
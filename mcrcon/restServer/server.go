@@ -1,36 +1,188 @@
 package restServer
 
 import (
+	"context"
 	"fmt"
 	"github.com/elazarl/go-bindata-assetfs"
 	"github.com/go-zoo/bone"
+	"github.com/joshproehl/minecontrol/formatting"
 	"github.com/joshproehl/minecontrol/mcrcon"
+	"github.com/joshproehl/minecontrol/mcrcon/cmdtree"
+	"github.com/joshproehl/minecontrol/ping"
+	"github.com/joshproehl/minecontrol/query"
+	jww "github.com/spf13/jwalterweatherman"
+	"net"
 	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
 type ServerConfig struct {
-	RCON_address  string
-	RCON_port     int
-	RCON_password string
-	Username      string
-	Password      string
-	Port          int
+	RCON_address     string
+	RCON_port        int
+	RCON_password    string
+	RCON_connections int
+	Query_port       int
+	Game_port        int
+	Minecraft_dir    string
+	Cmdtree_cache    string
+	Cmdtree_max_age  time.Duration
+	Cmdtree_refresh  bool // Discover the commands on startup even if there's a cached tree
+	Username         string
+	Password         string
+	Users            map[string]string // More usernames and passwords, as well as Username
+	Tokens_file      string
+	Public_gui       bool
+	Policy           PolicyConfig
+	Port             int
 }
 
-var rcon_client *mcrcon.MCRCONClient
+var rcon_pool *mcrcon.Pool
+var query_client *query.Client
+var game_address string
+var game_port int
+var minecraft_dir string
+var command_trees *cmdtree.Cache
+var command_tree *cmdtree.Tree
+var command_tree_m sync.RWMutex
+var api_auth *authenticator
+var api_policy *policy
 
 // By default go generate is going to build the production version. Run the command with -debug flag for
 // easier local development of static assets.
 //go:generate go-bindata-assetfs -pkg restServer -prefix "gui/assets/" gui/assets/...
 
+// requestedFormat reads the ?format= query parameter, which says how text from the server (containing § formatting
+// codes) should be rendered in responses. It defaults to plain text.
+func requestedFormat(r *http.Request) (formatting.Format, error) {
+	name := r.URL.Query().Get("format")
+	if name == "" {
+		return formatting.Plain, nil
+	}
+	return formatting.ParseFormat(name)
+}
+
+// loadCommandTree discovers the server's commands, for checking commands sent through the API before they reach the
+// server. Without one, commands go through unchecked. The cache rediscovers them once they're older than its MaxAge,
+// or straight away with refresh.
+func loadCommandTree(server string, refresh bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	version := ""
+	if status, err := ping.Ping(ctx, game_address, game_port); err == nil {
+		version = fmt.Sprintf("%s (%d)", status.Version.Name, status.Version.Protocol)
+	}
+
+	load := command_trees.Load
+	if refresh {
+		load = command_trees.Refresh
+	}
+
+	tree, err := load(ctx, rcon_pool, server, version)
+	if err != nil {
+		jww.WARN.Println("Could not discover the server's commands, so they won't be checked before sending:", err)
+		return
+	}
+
+	command_tree_m.Lock()
+	command_tree = tree
+	command_tree_m.Unlock()
+}
+
+// keepCommandTree reloads the command tree every so often, so it follows the server being upgraded or having plugins
+// added, and is picked up at all if the server couldn't be reached at startup.
+func keepCommandTree(server string) {
+	for range time.Tick(10 * time.Minute) {
+		loadCommandTree(server, false)
+	}
+}
+
+// checkCommand catches misspelt commands before they're sent, returning a *cmdtree.UnknownError with suggestions.
+func checkCommand(command string) error {
+	command_tree_m.RLock()
+	tree := command_tree
+	command_tree_m.RUnlock()
+
+	return tree.Validate(command)
+}
+
 // NewServer creates a server that will listen for requests over HTTP and interact with the RCON server specified
-// non-/api prefixed routes are served from static files compiled into bindata_assetfs.go
-func NewRestServer(c *ServerConfig) {
+// non-/api prefixed routes are served from static files compiled into bindata_assetfs.go. It only returns if the
+// server can't be set up, or stops listening.
+func NewRestServer(c *ServerConfig) error {
+	// Everything that can be wrong with the configuration is checked first, so it's reported before anything starts.
+	// The Query protocol is UDP, so there's nothing to connect to yet. This only fails if the address won't resolve.
 	var err error
-	rcon_client, err = mcrcon.NewClient(c.RCON_address, c.RCON_port, c.RCON_password)
+	query_client, err = query.NewClient(c.RCON_address, c.Query_port)
+	if err != nil {
+		return fmt.Errorf("Could not set up Query client for %s:%d. (Error was: %w)", c.RCON_address, c.Query_port, err)
+	}
 
+	// Passwords may be given as is, or as bcrypt hashes from "minecontrol hash-password". Usernames ignore case, since
+	// the config file's keys come to us lowercased.
+	users := make(map[string]string)
+	for name, password := range c.Users {
+		users[strings.ToLower(name)] = password
+	}
+	if c.Username != "" {
+		users[strings.ToLower(c.Username)] = c.Password
+	}
+
+	tokens, err := LoadTokenStore(c.Tokens_file)
+	if err != nil {
+		return fmt.Errorf("Could not load API tokens. (Error was: %w)", err)
+	}
+
+	api_auth = newAuthenticator(users, tokens, c.Public_gui)
+	if !api_auth.required {
+		jww.WARN.Println("No users or API tokens are set up, so anyone who can reach the server can use the API. Restart the server after adding some to require them.")
+	}
+
+	api_policy, err = newPolicy(c.Policy)
+	if err != nil {
+		return fmt.Errorf("Invalid policy. (Error was: %w)", err)
+	}
+
+	// Each request gets its own pooled connection, so one slow command doesn't hold up the others. Broken connections
+	// are replaced by the pool, so we carry on if the Minecraft server is restarted.
+	rcon_pool = mcrcon.NewPool(c.RCON_address, c.RCON_port, c.RCON_password, mcrcon.PoolConfig{
+		Size:        c.RCON_connections,
+		MaxWait:     10 * time.Second,
+		IdleTimeout: 5 * time.Minute,
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	err = rcon_pool.Ping(ctx)
+	cancel()
+
+	// Carry on without RCON if need be; /api/status doesn't use it, and the pool will connect once the server is reachable.
 	if err != nil {
-		panic(fmt.Errorf("Could not connect to RCON server at %s:%d. (Error was: %s)", c.RCON_address, c.RCON_port, err))
+		jww.ERROR.Println(fmt.Sprintf("Could not connect to RCON server at %s:%d. (Error was: %s)", c.RCON_address, c.RCON_port, err))
+	}
+
+	game_address, game_port = c.RCON_address, c.Game_port
+
+	cacheDir := c.Cmdtree_cache
+	if cacheDir == "" {
+		cacheDir = cmdtree.DefaultCacheDir()
+	}
+	command_trees = cmdtree.NewCache(cacheDir)
+	command_trees.MaxAge = c.Cmdtree_max_age
+	server := net.JoinHostPort(c.RCON_address, strconv.Itoa(c.RCON_port))
+	if err == nil {
+		loadCommandTree(server, c.Cmdtree_refresh)
+	}
+	go keepCommandTree(server)
+	minecraft_dir = c.Minecraft_dir
+
+	// The console streams the server's log, which only we can get at if we know where the server's files are.
+	if minecraft_dir != "" {
+		console_log = newLogTail(filepath.Join(minecraft_dir, "logs", "latest.log"))
+		go console_log.run(nil)
 	}
 
 	router := bone.New()
@@ -45,10 +197,13 @@ func NewRestServer(c *ServerConfig) {
 	router.GetFunc("/api", apiRootHandler)
 	router.GetFunc("/api/users", usersRootHandler)
 	router.GetFunc("/api/users/:username", usernameHandler)
-
-	// TODO: Require a http basic auth username and password if passed in.
+	router.GetFunc("/api/query", queryHandler)
+	router.GetFunc("/api/status", statusHandler)
+	router.PostFunc("/api/commands", commandsHandler)
+	router.GetFunc("/api/console", consoleHandler)
+	router.NotFoundFunc(notFoundHandler)
 
 	// Start the server
 	fmt.Println("Starting server on port", c.Port)
-	http.ListenAndServe(fmt.Sprintf(":%d", c.Port), router)
+	return http.ListenAndServe(fmt.Sprintf(":%d", c.Port), recoverer(api_auth.middleware(router)))
 }
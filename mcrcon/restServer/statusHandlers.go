@@ -0,0 +1,31 @@
+// Handle the /api/status route
+
+package restServer
+
+import (
+	"fmt"
+	"github.com/joshproehl/minecontrol/formatting"
+	"github.com/joshproehl/minecontrol/ping"
+	"net/http"
+)
+
+// Handle a request to the /status resource. This uses the Server List Ping rather than RCON, so it works even when RCON
+// is down or the password is wrong. The MOTD is rendered according to ?format=.
+func statusHandler(w http.ResponseWriter, r *http.Request) {
+	format, err := requestedFormat(r)
+	if err != nil {
+		writeProblem(w, newProblem(http.StatusBadRequest, codeInvalidRequest, err.Error()))
+		return
+	}
+
+	status, err := ping.Ping(r.Context(), game_address, game_port)
+
+	if err != nil {
+		writeProblem(w, newProblem(http.StatusBadGateway, codeUpstreamFailed, fmt.Sprintf("Server did not answer ping: %s", err)))
+		return
+	}
+
+	status.MOTD = formatting.Render(status.MOTD, format)
+
+	writeJSON(w, http.StatusOK, status)
+}
@@ -0,0 +1,200 @@
+// API tokens, for programs using the REST API rather than people
+
+package restServer
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenPrefix starts every token, so they're easy to recognise, e.g. when scanning for leaked secrets.
+const tokenPrefix = "mct_"
+
+// ErrTokenNotFound is returned by TokenStore.Revoke when there's no token with the given ID or name.
+var ErrTokenNotFound = errors.New("No such token")
+
+// TokenInfo describes an API token. The token itself is only ever shown when it's created; after that all we keep is a
+// hash of it.
+type TokenInfo struct {
+	ID      string    `json:"id"`
+	Name    string    `json:"name"`
+	Hash    string    `json:"hash"`
+	Created time.Time `json:"created"`
+}
+
+// TokenStore is the set of valid API tokens, kept in a JSON file. Changes to the file made by other processes, like
+// "minecontrol token revoke" while the server is running, are picked up the next time a token is checked.
+type TokenStore struct {
+	path string
+
+	m       sync.Mutex
+	tokens  []TokenInfo
+	modTime time.Time
+	size    int64
+}
+
+// LoadTokenStore reads the tokens in the file at path. A missing file is the same as one with no tokens in it.
+func LoadTokenStore(path string) (*TokenStore, error) {
+	ts := &TokenStore{path: path}
+	if err := ts.reload(); err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// reload re-reads the file if it's changed since we last read it. Must be called with the store locked, or before
+// anyone else has it.
+func (ts *TokenStore) reload() error {
+	fi, err := os.Stat(ts.path)
+	if os.IsNotExist(err) {
+		ts.tokens, ts.modTime, ts.size = nil, time.Time{}, 0
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if fi.ModTime().Equal(ts.modTime) && fi.Size() == ts.size {
+		return nil
+	}
+
+	b, err := os.ReadFile(ts.path)
+	if err != nil {
+		return err
+	}
+
+	var tokens []TokenInfo
+	if err := json.Unmarshal(b, &tokens); err != nil {
+		return fmt.Errorf("Could not read tokens from %s. (Error was: %s)", ts.path, err)
+	}
+	ts.tokens, ts.modTime, ts.size = tokens, fi.ModTime(), fi.Size()
+
+	return nil
+}
+
+// save writes the tokens back to the file, readable only by its owner. Must be called with the store locked.
+func (ts *TokenStore) save() error {
+	b, err := json.MarshalIndent(ts.tokens, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := ts.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, ts.path); err != nil {
+		return err
+	}
+
+	if fi, err := os.Stat(ts.path); err == nil {
+		ts.modTime, ts.size = fi.ModTime(), fi.Size()
+	}
+	return nil
+}
+
+// Create makes a new token with a name to remember it by, returning the token itself, which can't be recovered later.
+func (ts *TokenStore) Create(name string) (string, TokenInfo, error) {
+	secret := make([]byte, 24)
+	if _, err := rand.Read(secret); err != nil {
+		return "", TokenInfo{}, err
+	}
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return "", TokenInfo{}, err
+	}
+
+	token := tokenPrefix + hex.EncodeToString(secret)
+	info := TokenInfo{ID: hex.EncodeToString(id), Name: name, Hash: hashToken(token), Created: time.Now().UTC()}
+
+	ts.m.Lock()
+	defer ts.m.Unlock()
+
+	if err := ts.reload(); err != nil {
+		return "", TokenInfo{}, err
+	}
+	ts.tokens = append(ts.tokens, info)
+	if err := ts.save(); err != nil {
+		return "", TokenInfo{}, err
+	}
+
+	return token, info, nil
+}
+
+// List returns all the tokens.
+func (ts *TokenStore) List() ([]TokenInfo, error) {
+	ts.m.Lock()
+	defer ts.m.Unlock()
+
+	if err := ts.reload(); err != nil {
+		return nil, err
+	}
+	return append([]TokenInfo(nil), ts.tokens...), nil
+}
+
+// Revoke deletes the tokens with the given ID or name, so they stop working.
+func (ts *TokenStore) Revoke(idOrName string) error {
+	ts.m.Lock()
+	defer ts.m.Unlock()
+
+	if err := ts.reload(); err != nil {
+		return err
+	}
+
+	var kept []TokenInfo
+	for _, t := range ts.tokens {
+		if t.ID != idOrName && t.Name != idOrName {
+			kept = append(kept, t)
+		}
+	}
+	if len(kept) == len(ts.tokens) {
+		return fmt.Errorf("%w: %q", ErrTokenNotFound, idOrName)
+	}
+	ts.tokens = kept
+
+	return ts.save()
+}
+
+// Check returns the token's details if it's valid.
+func (ts *TokenStore) Check(token string) (*TokenInfo, bool) {
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return nil, false
+	}
+	hash := []byte(hashToken(token))
+
+	ts.m.Lock()
+	defer ts.m.Unlock()
+
+	// If the file can't be read, keep going with what we had rather than locking everyone out.
+	ts.reload()
+
+	for i := range ts.tokens {
+		if subtle.ConstantTimeCompare(hash, []byte(ts.tokens[i].Hash)) == 1 {
+			info := ts.tokens[i]
+			return &info, true
+		}
+	}
+	return nil, false
+}
+
+// Empty reports whether there are no tokens.
+func (ts *TokenStore) Empty() bool {
+	ts.m.Lock()
+	defer ts.m.Unlock()
+	ts.reload()
+	return len(ts.tokens) == 0
+}
+
+// hashToken is how tokens are stored. They're long and random, so unlike passwords they don't need a slow hash.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
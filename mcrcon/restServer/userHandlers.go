@@ -3,29 +3,114 @@
 package restServer
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"github.com/go-zoo/bone"
+	"github.com/joshproehl/minecontrol/formatting"
+	"github.com/joshproehl/minecontrol/mcrcon"
+	jww "github.com/spf13/jwalterweatherman"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
 )
 
-// Handle a request to the /users resource
+// Handle a request to the /users resource, listing who's online. Names are rendered according to ?format=.
 func usersRootHandler(w http.ResponseWriter, r *http.Request) {
-	userList, cmdErr := rcon_client.SendCommand("/list")
+	format, err := requestedFormat(r)
+	if err != nil {
+		writeProblem(w, newProblem(http.StatusBadRequest, codeInvalidRequest, err.Error()))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), commandTimeout)
+	defer cancel()
+
+	userList, cmdErr := mcrcon.ListPlayers(ctx, commanderFor(r))
 
 	if cmdErr != nil {
-		panic(cmdErr)
+		writeProblem(w, errorProblem(cmdErr))
+		return
 	}
 
-	if err := json.NewEncoder(w).Encode(userList); err != nil {
-		panic(err)
+	for i := range userList.Players {
+		userList.Players[i].Name = formatting.Render(userList.Players[i].Name, format)
 	}
+
+	writeJSON(w, http.StatusOK, userList)
 }
 
-// Handle a request tho the root reesource
+// Handle a request to a single user's resource, with everything the server can tell us about them. The name is
+// rendered according to ?format=.
 func usernameHandler(w http.ResponseWriter, r *http.Request) {
+	format, err := requestedFormat(r)
+	if err != nil {
+		writeProblem(w, newProblem(http.StatusBadRequest, codeInvalidRequest, err.Error()))
+		return
+	}
+
 	username := bone.GetValue(r, "username")
 
-	if err := json.NewEncoder(w).Encode(username); err != nil {
-		panic(err)
+	if !mcrcon.ValidPlayerName(username) {
+		writeProblem(w, newProblem(http.StatusNotFound, codeNotFound, fmt.Sprintf("%q isn't a valid player name.", username)))
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), commandTimeout)
+	defer cancel()
+
+	details, err := mcrcon.GetPlayerDetails(ctx, commanderFor(r), username)
+	if err != nil {
+		writeProblem(w, errorProblem(err))
+		return
+	}
+
+	// The server's own files are better sources than what it says over RCON, where ban list entries run together, and
+	// are the only place to find ops and players who have been on before.
+	details.Op = inPlayerFile("ops.json", details.Name)
+	details.Played = inPlayerFile("usercache.json", details.Name)
+	if banned := inPlayerFile("banned-players.json", details.Name); banned != nil {
+		details.Banned = *banned
+	}
+
+	if !details.Known() {
+		writeProblem(w, newProblem(http.StatusNotFound, codeNotFound, fmt.Sprintf("The server doesn't know of a player called %q.", username)))
+		return
+	}
+
+	details.Name = formatting.Render(details.Name, format)
+
+	writeJSON(w, http.StatusOK, details)
+}
+
+// inPlayerFile checks one of the server's lists of players, like ops.json, for the player. It returns nil if we don't
+// know where the server's files are, or can't read the file.
+func inPlayerFile(file string, username string) *bool {
+	if minecraft_dir == "" {
+		return nil
+	}
+
+	f, err := os.Open(filepath.Join(minecraft_dir, file))
+	if err != nil {
+		jww.WARN.Println(fmt.Sprintf("Could not read %s: %s", file, err))
+		return nil
+	}
+	defer f.Close()
+
+	var players []struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(f).Decode(&players); err != nil {
+		jww.WARN.Println(fmt.Sprintf("Could not parse %s: %s", file, err))
+		return nil
+	}
+
+	found := false
+	for _, p := range players {
+		if strings.EqualFold(p.Name, username) {
+			found = true
+		}
 	}
+	return &found
 }
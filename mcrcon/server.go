@@ -0,0 +1,272 @@
+package mcrcon
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"runtime"
+	"sync"
+)
+
+// ErrServerClosed is returned by Server.Serve and ListenAndServe once Close has been called.
+var ErrServerClosed = errors.New("mcrcon: Server closed")
+
+// Handler responds to an RCON command. Whatever is written to w is sent back to the client once ServeRCON returns, split
+// over as many packets as it takes. Commands from one session are handled in order, but ServeRCON may be called
+// concurrently for different sessions.
+type Handler interface {
+	ServeRCON(w io.Writer, r *Request)
+}
+
+// HandlerFunc lets an ordinary function be used as a Handler.
+type HandlerFunc func(w io.Writer, r *Request)
+
+// ServeRCON calls f(w, r).
+func (f HandlerFunc) ServeRCON(w io.Writer, r *Request) {
+	f(w, r)
+}
+
+// Request is a single command received by a Server.
+type Request struct {
+	ID         int32  // The request ID the client sent; the response goes back with the same one
+	Command    string // The command payload, exactly as sent
+	RemoteAddr string // The client's network address
+	Identity   string // Who logged in to the session, as returned by Server.Authenticate. Empty when using Password.
+
+	ctx context.Context
+}
+
+// Context returns a context that's cancelled when the client disconnects or the server is closed.
+func (r *Request) Context() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+	return r.ctx
+}
+
+// Server accepts RCON connections, authenticates them, and passes their commands to Handler. It uses the same packet
+// codec as MCRCONClient, and answers clients the way the vanilla server does, so Minecraft RCON tools (including
+// MCRCONClient) can talk to it.
+type Server struct {
+	Addr     string // TCP address to listen on for ListenAndServe, ":25575" if empty
+	Password string // The password clients must log in with
+
+	// Authenticate, if set, is used instead of Password to check a login. It returns the identity to attach to that
+	// session's requests, so one server can tell apart clients with different passwords.
+	Authenticate func(password string) (identity string, ok bool)
+
+	Handler Handler
+
+	// ErrorLog is where a panic in Handler is logged, before the session it happened in is closed. If nil, the log
+	// package's standard logger is used.
+	ErrorLog *log.Logger
+
+	m         sync.Mutex
+	listeners map[net.Listener]struct{}
+	conns     map[net.Conn]struct{}
+	closed    bool
+	wg        sync.WaitGroup
+}
+
+// ListenAndServe listens on srv.Addr and then calls Serve.
+func (srv *Server) ListenAndServe() error {
+	addr := srv.Addr
+	if addr == "" {
+		addr = ":25575"
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	return srv.Serve(l)
+}
+
+// Serve accepts connections on l, handling each on its own goroutine, until l fails or the server is closed.
+// It always returns a non-nil error, ErrServerClosed after Close.
+func (srv *Server) Serve(l net.Listener) error {
+	srv.m.Lock()
+	if srv.closed {
+		srv.m.Unlock()
+		l.Close()
+		return ErrServerClosed
+	}
+	if srv.listeners == nil {
+		srv.listeners = make(map[net.Listener]struct{})
+	}
+	srv.listeners[l] = struct{}{}
+	srv.m.Unlock()
+
+	defer func() {
+		srv.m.Lock()
+		delete(srv.listeners, l)
+		srv.m.Unlock()
+		l.Close()
+	}()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			srv.m.Lock()
+			closed := srv.closed
+			srv.m.Unlock()
+			if closed {
+				return ErrServerClosed
+			}
+			return err
+		}
+
+		if !srv.track(conn) {
+			conn.Close()
+			return ErrServerClosed
+		}
+
+		srv.wg.Add(1)
+		go srv.serveConn(conn)
+	}
+}
+
+// Close stops all listeners, hangs up on every session, and waits for running handlers to return.
+func (srv *Server) Close() error {
+	srv.m.Lock()
+	srv.closed = true
+	for l := range srv.listeners {
+		l.Close()
+	}
+	for c := range srv.conns {
+		c.Close()
+	}
+	srv.m.Unlock()
+
+	srv.wg.Wait()
+	return nil
+}
+
+func (srv *Server) track(conn net.Conn) bool {
+	srv.m.Lock()
+	defer srv.m.Unlock()
+
+	if srv.closed {
+		return false
+	}
+	if srv.conns == nil {
+		srv.conns = make(map[net.Conn]struct{})
+	}
+	srv.conns[conn] = struct{}{}
+	return true
+}
+
+func (srv *Server) serveConn(conn net.Conn) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	defer srv.wg.Done()
+	defer func() {
+		cancel()
+		srv.m.Lock()
+		delete(srv.conns, conn)
+		srv.m.Unlock()
+		conn.Close()
+	}()
+
+	// A panicking handler only costs its own session, as with net/http, rather than the whole process.
+	defer func() {
+		if err := recover(); err != nil {
+			buf := make([]byte, 64<<10)
+			buf = buf[:runtime.Stack(buf, false)]
+			srv.logf("mcrcon: panic serving %v: %v\n%s", conn.RemoteAddr(), err, buf)
+		}
+	}()
+
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	authed := false
+	identity := ""
+
+	for {
+		pkt, err := DecodePacket(r)
+		if err != nil {
+			return
+		}
+
+		var out []Packet
+		switch pkt.Type {
+		case PacketTypeLogin:
+			identity, authed = srv.login(pkt.Payload)
+			id := pkt.ID
+			if !authed {
+				id = -1
+			}
+			out = []Packet{{ID: id, Type: PacketTypeCommand}}
+
+		case PacketTypeCommand:
+			if !authed {
+				out = []Packet{{ID: -1, Type: PacketTypeResponse}}
+				break
+			}
+
+			var buf bytes.Buffer
+			if srv.Handler != nil {
+				srv.Handler.ServeRCON(&buf, &Request{
+					ID:         pkt.ID,
+					Command:    pkt.Payload,
+					RemoteAddr: conn.RemoteAddr().String(),
+					Identity:   identity,
+					ctx:        ctx,
+				})
+			}
+			out = fragment(pkt.ID, buf.String())
+
+		default:
+			// Vanilla answers anything it doesn't understand like this, and MCRCONClient relies on getting an answer
+			// to find the end of a multi-packet response.
+			out = []Packet{{ID: pkt.ID, Type: PacketTypeResponse, Payload: fmt.Sprintf("Unknown request %x", int32(pkt.Type))}}
+		}
+
+		for _, p := range out {
+			if err := EncodePacket(w, p); err != nil {
+				return
+			}
+		}
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (srv *Server) logf(format string, args ...interface{}) {
+	if srv.ErrorLog != nil {
+		srv.ErrorLog.Printf(format, args...)
+	} else {
+		log.Printf(format, args...)
+	}
+}
+
+func (srv *Server) login(password string) (string, bool) {
+	if srv.Authenticate != nil {
+		return srv.Authenticate(password)
+	}
+	return "", subtle.ConstantTimeCompare([]byte(password), []byte(srv.Password)) == 1
+}
+
+// fragment splits a response into packets of at most maxFragmentLength bytes of payload. An empty response is still
+// sent as one empty packet.
+func fragment(id int32, response string) []Packet {
+	var pkts []Packet
+	for {
+		n := len(response)
+		if n > maxFragmentLength {
+			n = maxFragmentLength
+		}
+		pkts = append(pkts, Packet{ID: id, Type: PacketTypeResponse, Payload: response[:n]})
+		response = response[n:]
+		if response == "" {
+			return pkts
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package mcrcon
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// startServer runs srv on a random local port until the test ends, returning the port.
+func startServer(t *testing.T, srv *Server) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- srv.Serve(l)
+	}()
+	t.Cleanup(func() {
+		srv.Close()
+		if err := <-done; !errors.Is(err, ErrServerClosed) {
+			t.Errorf("Serve returned %v, want ErrServerClosed", err)
+		}
+	})
+
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func echoHandler(w io.Writer, r *Request) {
+	fmt.Fprintf(w, "%s ran %q", r.Identity, r.Command)
+}
+
+func TestServerAuth(t *testing.T) {
+	port := startServer(t, &Server{Password: "secret", Handler: HandlerFunc(echoHandler)})
+
+	c, err := NewClient("127.0.0.1", port, "secret")
+	if err != nil {
+		t.Fatalf("login with the right password: %v", err)
+	}
+	defer c.Close()
+
+	got, err := c.SendCommand("list")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := ` ran "list"`; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	if _, err := NewClient("127.0.0.1", port, "wrong"); !errors.Is(err, ErrAuthFailed) {
+		t.Errorf("login with the wrong password: got %v, want ErrAuthFailed", err)
+	}
+}
+
+func TestServerRejectsCommandsBeforeLogin(t *testing.T) {
+	port := startServer(t, &Server{Password: "secret", Handler: HandlerFunc(echoHandler)})
+
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	EncodePacket(conn, Packet{ID: 9, Type: PacketTypeCommand, Payload: "stop"})
+	pkt, err := DecodePacket(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkt.ID != -1 {
+		t.Errorf("command before login answered with ID %d, want -1", pkt.ID)
+	}
+}
+
+func TestServerIdentity(t *testing.T) {
+	port := startServer(t, &Server{
+		Authenticate: func(password string) (string, bool) {
+			switch password {
+			case "alice-pw":
+				return "alice", true
+			case "bob-pw":
+				return "bob", true
+			}
+			return "", false
+		},
+		Handler: HandlerFunc(echoHandler),
+	})
+
+	for _, who := range []string{"alice", "bob"} {
+		c, err := NewClient("127.0.0.1", port, who+"-pw")
+		if err != nil {
+			t.Fatalf("%s: %v", who, err)
+		}
+		got, err := c.SendCommand("say hi")
+		c.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want := who + ` ran "say hi"`; got != want {
+			t.Errorf("got %q, want %q", got, want)
+		}
+	}
+
+	if _, err := NewClient("127.0.0.1", port, "secret"); !errors.Is(err, ErrAuthFailed) {
+		t.Errorf("unknown password: got %v, want ErrAuthFailed", err)
+	}
+}
+
+func TestServerFragmentsLongResponses(t *testing.T) {
+	long := strings.Repeat("0123456789", 1000)
+	port := startServer(t, &Server{Password: "secret", Handler: HandlerFunc(func(w io.Writer, r *Request) {
+		io.WriteString(w, long)
+	})})
+
+	// Read the packets directly, to see how the response was split.
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+
+	EncodePacket(conn, Packet{ID: 1, Type: PacketTypeLogin, Payload: "secret"})
+	if pkt, err := DecodePacket(r); err != nil || pkt.ID != 1 {
+		t.Fatalf("login: %+v, %v", pkt, err)
+	}
+
+	EncodePacket(conn, Packet{ID: 2, Type: PacketTypeCommand, Payload: "help"})
+	var sizes []int
+	var got strings.Builder
+	for got.Len() < len(long) {
+		pkt, err := DecodePacket(r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pkt.ID != 2 {
+			t.Fatalf("fragment has ID %d, want 2", pkt.ID)
+		}
+		sizes = append(sizes, len(pkt.Payload))
+		got.WriteString(pkt.Payload)
+	}
+
+	if want := []int{4096, 4096, 1808}; fmt.Sprint(sizes) != fmt.Sprint(want) {
+		t.Errorf("fragment sizes %v, want %v", sizes, want)
+	}
+	if got.String() != long {
+		t.Error("fragments don't add up to the response")
+	}
+
+	// And MCRCONClient puts them back together.
+	c, err := NewClient("127.0.0.1", port, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if resp, err := c.SendCommand("help"); err != nil || resp != long {
+		t.Errorf("client got %d bytes, %v, want %d", len(resp), err, len(long))
+	}
+}
+
+func TestServerRecoversHandlerPanic(t *testing.T) {
+	var logged bytes.Buffer
+	var m sync.Mutex
+	srv := &Server{
+		Password: "secret",
+		ErrorLog: log.New(lockedWriter{&m, &logged}, "", 0),
+		Handler: HandlerFunc(func(w io.Writer, r *Request) {
+			if r.Command == "boom" {
+				panic("handler blew up")
+			}
+			echoHandler(w, r)
+		}),
+	}
+	port := startServer(t, srv)
+
+	c, err := NewClient("127.0.0.1", port, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, err := c.SendCommand("boom"); err == nil {
+		t.Error("a command whose handler panicked succeeded")
+	}
+
+	// Only that session is lost.
+	c2, err := NewClient("127.0.0.1", port, "secret")
+	if err != nil {
+		t.Fatalf("server not accepting connections after a panic: %v", err)
+	}
+	defer c2.Close()
+	if _, err := c2.SendCommand("list"); err != nil {
+		t.Errorf("command after a panic: %v", err)
+	}
+
+	m.Lock()
+	defer m.Unlock()
+	if !strings.Contains(logged.String(), "handler blew up") {
+		t.Errorf("panic wasn't logged: %q", logged.String())
+	}
+}
+
+type lockedWriter struct {
+	m *sync.Mutex
+	w io.Writer
+}
+
+func (lw lockedWriter) Write(p []byte) (int, error) {
+	lw.m.Lock()
+	defer lw.m.Unlock()
+	return lw.w.Write(p)
+}
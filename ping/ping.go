@@ -0,0 +1,478 @@
+// ping implements Minecraft's Server List Ping, the status protocol the multiplayer menu uses. It works against any
+// server that players can connect to, with no RCON or Query setup needed, and reports the version, player counts, a
+// sample of who's online, the MOTD, the favicon and the round trip latency.
+package ping
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/joshproehl/minecontrol/formatting"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+/*
+From wiki.vg/Server_List_Ping:
+
+	Packets are a VarInt length, a VarInt packet ID, then the fields. Strings are a VarInt byte count then UTF-8.
+
+	C->S Handshake      0x00  protocol version (VarInt), server address (String), port (unsigned short), next state 1 (VarInt)
+	C->S Status Request 0x00  no fields
+	S->C Status Response 0x00 JSON response (String)
+	C->S Ping           0x01  payload (Long)
+	S->C Pong           0x01  the same payload (Long)
+
+Servers from before 1.7 don't understand this, and are asked with the 1.6 legacy ping instead:
+
+	C->S FE 01 FA, then "MC|PingHost" and the host as UTF-16BE strings prefixed with their length in characters, along
+	     with the protocol version and port.
+	S->C FF, a short length in characters, then a UTF-16BE string:
+	     "§1\x00<protocol>\x00<version>\x00<motd>\x00<online>\x00<max>"
+	     Servers older than 1.4 just send "<motd>§<online>§<max>".
+*/
+
+// defaultTimeout applies when the caller's context has no deadline.
+const defaultTimeout = 5 * time.Second
+
+// maxPacketLength guards against a bogus length making us allocate a huge buffer. Favicons make the status response the
+// biggest thing we'll see, and they're nowhere near this.
+const maxPacketLength = 1 << 21
+
+// ErrBadResponse is returned when the server's reply can't be parsed.
+var ErrBadResponse = errors.New("ping: malformed response")
+
+// Status is what the server reports about itself.
+type Status struct {
+	Version struct {
+		Name     string `json:"name"`
+		Protocol int    `json:"protocol"`
+	} `json:"version"`
+	Players struct {
+		Online int            `json:"online"`
+		Max    int            `json:"max"`
+		Sample []PlayerSample `json:"sample,omitempty"`
+	} `json:"players"`
+
+	// Description is the MOTD as the server sent it, either a plain JSON string or a chat component.
+	Description json.RawMessage `json:"description,omitempty"`
+
+	// MOTD is Description flattened to text. Component colours and decorations become § formatting codes, and any the
+	// server wrote into the text itself are kept.
+	MOTD string `json:"motd"`
+
+	// Favicon is a data: URI holding a 64x64 PNG, if the server has an icon.
+	Favicon string `json:"favicon,omitempty"`
+
+	LatencyMS int64 `json:"latencyMs"`
+	Legacy    bool  `json:"legacy"` // Whether the pre-1.7 protocol had to be used
+}
+
+// PlayerSample is one of the online players the server chose to list.
+type PlayerSample struct {
+	Name string `json:"name"`
+	ID   string `json:"id"`
+}
+
+// Ping asks the server at addr:port (the port players connect to, usually 25565) for its status. Servers that don't
+// understand the modern protocol are asked again using the 1.6 legacy ping.
+func Ping(ctx context.Context, addr string, port int) (*Status, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultTimeout)
+		defer cancel()
+	}
+
+	status, err := pingModern(ctx, addr, port)
+	if err == nil {
+		return status, nil
+	}
+	if ctx.Err() != nil {
+		return nil, err
+	}
+
+	status, legacyErr := pingLegacy(ctx, addr, port)
+	if legacyErr != nil {
+		return nil, fmt.Errorf("%s (legacy ping also failed: %s)", err, legacyErr)
+	}
+	return status, nil
+}
+
+func dial(ctx context.Context, addr string, port int) (net.Conn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(addr, strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	return conn, nil
+}
+
+func pingModern(ctx context.Context, addr string, port int) (*Status, error) {
+	conn, err := dial(ctx, addr, port)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+
+	var handshake bytes.Buffer
+	writeVarInt(&handshake, -1) // We don't know which version the server is, and -1 is the convention for that
+	writeString(&handshake, addr)
+	binary.Write(&handshake, binary.BigEndian, uint16(port))
+	writeVarInt(&handshake, 1)
+
+	if err := writePacket(conn, 0x00, handshake.Bytes()); err != nil {
+		return nil, err
+	}
+	if err := writePacket(conn, 0x00, nil); err != nil {
+		return nil, err
+	}
+
+	id, body, err := readPacket(r)
+	if err != nil {
+		return nil, err
+	}
+	if id != 0x00 {
+		return nil, fmt.Errorf("%w: expected status response, got packet %#x", ErrBadResponse, id)
+	}
+
+	js, err := readString(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	status := &Status{}
+	if err := json.Unmarshal([]byte(js), status); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBadResponse, err)
+	}
+	status.MOTD = flattenDescription(status.Description)
+
+	// The status response is done with, the ping/pong is just to time a round trip.
+	var payload [8]byte
+	sent := time.Now()
+	binary.BigEndian.PutUint64(payload[:], uint64(sent.UnixNano()))
+	if err := writePacket(conn, 0x01, payload[:]); err != nil {
+		return nil, err
+	}
+	id, body, err = readPacket(r)
+	if err != nil {
+		return nil, err
+	}
+	if id != 0x01 || !bytes.Equal(body, payload[:]) {
+		return nil, fmt.Errorf("%w: pong doesn't match ping", ErrBadResponse)
+	}
+	status.LatencyMS = time.Since(sent).Milliseconds()
+
+	return status, nil
+}
+
+func pingLegacy(ctx context.Context, addr string, port int) (*Status, error) {
+	conn, err := dial(ctx, addr, port)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	hostname := utf16.Encode([]rune(addr))
+
+	var req bytes.Buffer
+	req.Write([]byte{0xFE, 0x01, 0xFA})
+	writeUTF16String(&req, utf16.Encode([]rune("MC|PingHost")))
+	binary.Write(&req, binary.BigEndian, uint16(7+2*len(hostname)))
+	req.WriteByte(74) // Protocol version of 1.6.2
+	writeUTF16String(&req, hostname)
+	binary.Write(&req, binary.BigEndian, int32(port))
+
+	sent := time.Now()
+	if _, err := conn.Write(req.Bytes()); err != nil {
+		return nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	kick, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if kick != 0xFF {
+		return nil, fmt.Errorf("%w: expected kick packet, got %#x", ErrBadResponse, kick)
+	}
+	latency := time.Since(sent)
+
+	var length uint16
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+	chars := make([]uint16, length)
+	if err := binary.Read(r, binary.BigEndian, chars); err != nil {
+		return nil, err
+	}
+	reply := string(utf16.Decode(chars))
+
+	status := &Status{Legacy: true, LatencyMS: latency.Milliseconds()}
+
+	if strings.HasPrefix(reply, "§1\x00") {
+		fields := strings.Split(reply, "\x00")
+		if len(fields) < 6 {
+			return nil, fmt.Errorf("%w: %d fields in legacy response", ErrBadResponse, len(fields))
+		}
+		status.Version.Protocol, _ = strconv.Atoi(fields[1])
+		status.Version.Name = fields[2]
+		status.MOTD = fields[3]
+		status.Players.Online, _ = strconv.Atoi(fields[4])
+		status.Players.Max, _ = strconv.Atoi(fields[5])
+	} else {
+		// Pre-1.4: the MOTD can't contain §, so the last two are the counts.
+		fields := strings.Split(reply, "§")
+		if len(fields) < 3 {
+			return nil, fmt.Errorf("%w: %d fields in legacy response", ErrBadResponse, len(fields))
+		}
+		n := len(fields)
+		status.MOTD = strings.Join(fields[:n-2], "§")
+		status.Players.Online, _ = strconv.Atoi(fields[n-2])
+		status.Players.Max, _ = strconv.Atoi(fields[n-1])
+	}
+
+	return status, nil
+}
+
+// flattenDescription turns the description into text. It's either a JSON string, or a chat component whose text is
+// spread through "text", "translate" and nested "extra" components. Component colours and decorations are turned back
+// into § codes, so the MOTD renders the same way whichever form the server used.
+func flattenDescription(raw json.RawMessage) string {
+	var f flattener
+	f.component(raw, formatting.Style{})
+	return f.b.String()
+}
+
+// component is the JSON form of a chat component. The style fields are pointers since a component only overrides the
+// ones it sets, and inherits the rest from its parent.
+type component struct {
+	Text          string            `json:"text"`
+	Translate     string            `json:"translate"`
+	Fallback      string            `json:"fallback"`
+	With          []json.RawMessage `json:"with"`
+	Extra         []json.RawMessage `json:"extra"`
+	Color         string            `json:"color"`
+	Bold          *bool             `json:"bold"`
+	Italic        *bool             `json:"italic"`
+	Underlined    *bool             `json:"underlined"`
+	Strikethrough *bool             `json:"strikethrough"`
+	Obfuscated    *bool             `json:"obfuscated"`
+}
+
+// translatePlaceholderRe matches the %s and %1$s placeholders in a translation, and the %% escape.
+var translatePlaceholderRe = regexp.MustCompile(`%(?:(\d+)\$)?s|%%`)
+
+type flattener struct {
+	b     strings.Builder
+	style formatting.Style // The style the text written so far ends in
+}
+
+// component writes the component in raw, which may also be a plain string or an array of components, in the style
+// inherited from its parent. It returns the component's own style, which its children inherit.
+func (f *flattener) component(raw json.RawMessage, inherited formatting.Style) formatting.Style {
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		f.text(s, inherited)
+		return inherited
+	}
+
+	// An array is its first element, with the rest as its extra.
+	var list []json.RawMessage
+	if err := json.Unmarshal(raw, &list); err == nil {
+		if len(list) == 0 {
+			return inherited
+		}
+		style := f.component(list[0], inherited)
+		for _, e := range list[1:] {
+			f.component(e, style)
+		}
+		return style
+	}
+
+	var c component
+	if err := json.Unmarshal(raw, &c); err != nil {
+		return inherited
+	}
+	style := c.style(inherited)
+
+	if c.Translate != "" {
+		// We don't have the game's language files, so the key stands in for the translation unless a fallback was sent.
+		format := c.Fallback
+		if format == "" {
+			format = c.Translate
+		}
+		f.translation(format, c.With, style)
+	} else {
+		f.text(c.Text, style)
+	}
+
+	for _, e := range c.Extra {
+		f.component(e, style)
+	}
+	return style
+}
+
+func (c *component) style(inherited formatting.Style) formatting.Style {
+	st := inherited
+	if c.Color != "" {
+		st.Color = c.Color
+	}
+	for _, d := range []struct {
+		set *bool
+		to  *bool
+	}{
+		{c.Bold, &st.Bold},
+		{c.Italic, &st.Italic},
+		{c.Underlined, &st.Underlined},
+		{c.Strikethrough, &st.Strikethrough},
+		{c.Obfuscated, &st.Obfuscated},
+	} {
+		if d.set != nil {
+			*d.to = *d.set
+		}
+	}
+	return st
+}
+
+// translation writes format with its placeholders filled in from with.
+func (f *flattener) translation(format string, with []json.RawMessage, style formatting.Style) {
+	next := 0
+	last := 0
+	for _, m := range translatePlaceholderRe.FindAllStringSubmatchIndex(format, -1) {
+		f.text(format[last:m[0]], style)
+		last = m[1]
+
+		if format[m[0]:m[1]] == "%%" {
+			f.text("%", style)
+			continue
+		}
+
+		i := next
+		if m[2] >= 0 {
+			n, _ := strconv.Atoi(format[m[2]:m[3]])
+			i = n - 1
+		} else {
+			next++
+		}
+		if i >= 0 && i < len(with) {
+			f.component(with[i], style)
+		}
+	}
+	f.text(format[last:], style)
+}
+
+// text writes s, preceded by whatever codes are needed to switch to style.
+func (f *flattener) text(s string, style formatting.Style) {
+	if s == "" {
+		return
+	}
+	if style != f.style {
+		f.b.WriteString(style.Codes())
+		f.style = style
+	}
+	f.b.WriteString(s)
+}
+
+func writePacket(w io.Writer, id int32, body []byte) error {
+	var inner bytes.Buffer
+	writeVarInt(&inner, id)
+	inner.Write(body)
+
+	var pkt bytes.Buffer
+	writeVarInt(&pkt, int32(inner.Len()))
+	pkt.Write(inner.Bytes())
+
+	_, err := w.Write(pkt.Bytes())
+	return err
+}
+
+func readPacket(r *bufio.Reader) (int32, []byte, error) {
+	length, err := readVarInt(r)
+	if err != nil {
+		return 0, nil, err
+	}
+	if length < 1 || length > maxPacketLength {
+		return 0, nil, fmt.Errorf("%w: packet length %d", ErrBadResponse, length)
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, nil, err
+	}
+
+	br := bytes.NewReader(data)
+	id, err := readVarInt(br)
+	if err != nil {
+		return 0, nil, err
+	}
+	return id, data[len(data)-br.Len():], nil
+}
+
+// writeVarInt writes v using the protocol's variable length encoding: 7 bits at a time, least significant first, with
+// the top bit set on every byte but the last.
+func writeVarInt(w *bytes.Buffer, v int32) {
+	u := uint32(v)
+	for {
+		if u&^0x7F == 0 {
+			w.WriteByte(byte(u))
+			return
+		}
+		w.WriteByte(byte(u&0x7F) | 0x80)
+		u >>= 7
+	}
+}
+
+func readVarInt(r io.ByteReader) (int32, error) {
+	var v uint32
+	for i := 0; i < 5; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		v |= uint32(b&0x7F) << (7 * i)
+		if b&0x80 == 0 {
+			return int32(v), nil
+		}
+	}
+	return 0, fmt.Errorf("%w: VarInt too long", ErrBadResponse)
+}
+
+func writeString(w *bytes.Buffer, s string) {
+	writeVarInt(w, int32(len(s)))
+	w.WriteString(s)
+}
+
+func readString(r *bytes.Reader) (string, error) {
+	n, err := readVarInt(r)
+	if err != nil {
+		return "", err
+	}
+	if n < 0 || int(n) > r.Len() {
+		return "", fmt.Errorf("%w: string length %d", ErrBadResponse, n)
+	}
+
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return "", err
+	}
+	return string(buf), nil
+}
+
+func writeUTF16String(w *bytes.Buffer, s []uint16) {
+	binary.Write(w, binary.BigEndian, uint16(len(s)))
+	binary.Write(w, binary.BigEndian, s)
+}
@@ -0,0 +1,259 @@
+package ping
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestVarInt(t *testing.T) {
+	// From the examples on wiki.vg/Protocol#VarInt_and_VarLong.
+	tests := []struct {
+		v       int32
+		encoded []byte
+	}{
+		{0, []byte{0x00}},
+		{1, []byte{0x01}},
+		{2, []byte{0x02}},
+		{127, []byte{0x7f}},
+		{128, []byte{0x80, 0x01}},
+		{255, []byte{0xff, 0x01}},
+		{25565, []byte{0xdd, 0xc7, 0x01}},
+		{2097151, []byte{0xff, 0xff, 0x7f}},
+		{2147483647, []byte{0xff, 0xff, 0xff, 0xff, 0x07}},
+		{-1, []byte{0xff, 0xff, 0xff, 0xff, 0x0f}},
+		{-2147483648, []byte{0x80, 0x80, 0x80, 0x80, 0x08}},
+	}
+
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		writeVarInt(&buf, tt.v)
+		if !bytes.Equal(buf.Bytes(), tt.encoded) {
+			t.Errorf("writeVarInt(%d) = % x, want % x", tt.v, buf.Bytes(), tt.encoded)
+		}
+
+		got, err := readVarInt(bytes.NewReader(tt.encoded))
+		if err != nil || got != tt.v {
+			t.Errorf("readVarInt(% x) = %d, %v, want %d", tt.encoded, got, err, tt.v)
+		}
+	}
+}
+
+func TestReadVarIntErrors(t *testing.T) {
+	if _, err := readVarInt(bytes.NewReader([]byte{0x80, 0x80, 0x80, 0x80, 0x80, 0x01})); !errors.Is(err, ErrBadResponse) {
+		t.Errorf("6 byte VarInt: got %v, want ErrBadResponse", err)
+	}
+	if _, err := readVarInt(bytes.NewReader([]byte{0x80, 0x80})); err != io.EOF {
+		t.Errorf("truncated VarInt: got %v, want io.EOF", err)
+	}
+}
+
+func TestReadString(t *testing.T) {
+	var buf bytes.Buffer
+	writeString(&buf, "§6A Minecraft Server")
+	if s, err := readString(bytes.NewReader(buf.Bytes())); err != nil || s != "§6A Minecraft Server" {
+		t.Errorf("got %q, %v", s, err)
+	}
+
+	// The length says there's more than there is.
+	short := append([]byte{10}, "abc"...)
+	if _, err := readString(bytes.NewReader(short)); !errors.Is(err, ErrBadResponse) {
+		t.Errorf("short string: got %v, want ErrBadResponse", err)
+	}
+}
+
+func TestFlattenDescription(t *testing.T) {
+	tests := []struct {
+		name string
+		json string
+		want string
+	}{
+		{"string", `"A Minecraft Server"`, "A Minecraft Server"},
+		{"string with codes", `"§6Gold §rplain"`, "§6Gold §rplain"},
+		{"text", `{"text":"Hello"}`, "Hello"},
+		{"extra", `{"text":"Hello, ","extra":["world",{"text":"!"}]}`, "Hello, world!"},
+		{"color", `{"text":"Gold","color":"gold"}`, "§6Gold"},
+		{"hex color", `{"text":"Orange","color":"#FFAA00"}`, "§x§f§f§a§a§0§0Orange"},
+		{"decorations", `{"text":"Loud","bold":true,"italic":true}`, "§r§l§oLoud"},
+		{
+			"inherited style",
+			`{"text":"A ","color":"red","extra":[{"text":"bold","bold":true},{"text":" server"}]}`,
+			"§cA §c§lbold§c server",
+		},
+		{
+			"decoration switched off",
+			`{"text":"","bold":true,"extra":[{"text":"on "},{"text":"off","bold":false}]}`,
+			"§r§lon §roff",
+		},
+		{"back to default", `{"text":"","extra":[{"text":"Red","color":"red"}," plain"]}`, "§cRed§r plain"},
+		{"array", `[{"text":"Red ","color":"red"},"still red"]`, "§cRed still red"},
+		{
+			"translate",
+			`{"translate":"multiplayer.player.joined","fallback":"%s joined the game","with":[{"text":"Notch","color":"yellow"}]}`,
+			"§eNotch§r joined the game",
+		},
+		{"translate positional", `{"translate":"%2$s then %1$s, 100%%","with":["a","b"]}`, "b then a, 100%"},
+		{"translate without fallback", `{"translate":"menu.game"}`, "menu.game"},
+		{"empty", ``, ""},
+		{"not a component", `42`, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := flattenDescription(json.RawMessage(tt.json)); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// serve accepts a single connection on a local port and hands it to handle.
+func serve(t *testing.T, handle func(conn net.Conn)) int {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	go func() {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		handle(conn)
+	}()
+
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestPingModern(t *testing.T) {
+	const response = `{
+		"version": {"name": "Paper 1.20.4", "protocol": 765},
+		"players": {"max": 20, "online": 2, "sample": [{"name": "Notch", "id": "069a79f4-44e9-4726-a5be-fca90e38aaf5"}]},
+		"description": {"text": "Welcome", "color": "gold", "extra": [{"text": " to the server", "color": "white"}]},
+		"favicon": "data:image/png;base64,iVBORw0KGgo=",
+		"enforcesSecureChat": true
+	}`
+
+	port := serve(t, func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+
+		id, body, err := readPacket(r)
+		if err != nil || id != 0x00 {
+			t.Errorf("handshake: id %d, %v", id, err)
+			return
+		}
+		br := bytes.NewReader(body)
+		readVarInt(br)
+		if host, _ := readString(br); host != "127.0.0.1" {
+			t.Errorf("handshake host %q", host)
+		}
+
+		if id, _, err := readPacket(r); err != nil || id != 0x00 {
+			t.Errorf("status request: id %d, %v", id, err)
+			return
+		}
+		var status bytes.Buffer
+		writeString(&status, response)
+		writePacket(conn, 0x00, status.Bytes())
+
+		id, body, err = readPacket(r)
+		if err != nil || id != 0x01 {
+			t.Errorf("ping: id %d, %v", id, err)
+			return
+		}
+		writePacket(conn, 0x01, body)
+	})
+
+	status, err := Ping(context.Background(), "127.0.0.1", port)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if status.Legacy {
+		t.Error("modern ping reported as legacy")
+	}
+	if status.Version.Name != "Paper 1.20.4" || status.Version.Protocol != 765 {
+		t.Errorf("version %+v", status.Version)
+	}
+	if status.Players.Online != 2 || status.Players.Max != 20 {
+		t.Errorf("players %d/%d", status.Players.Online, status.Players.Max)
+	}
+	if len(status.Players.Sample) != 1 || status.Players.Sample[0].Name != "Notch" {
+		t.Errorf("sample %+v", status.Players.Sample)
+	}
+	if want := "§6Welcome§f to the server"; status.MOTD != want {
+		t.Errorf("MOTD %q, want %q", status.MOTD, want)
+	}
+	if status.Favicon != "data:image/png;base64,iVBORw0KGgo=" {
+		t.Errorf("favicon %q", status.Favicon)
+	}
+}
+
+// legacyReply is the kick packet a pre-1.7 server answers a ping with.
+func legacyReply(s string) []byte {
+	chars := utf16.Encode([]rune(s))
+	var buf bytes.Buffer
+	buf.WriteByte(0xFF)
+	binary.Write(&buf, binary.BigEndian, uint16(len(chars)))
+	binary.Write(&buf, binary.BigEndian, chars)
+	return buf.Bytes()
+}
+
+func TestPingLegacy(t *testing.T) {
+	tests := []struct {
+		name    string
+		reply   string
+		motd    string
+		version string
+		online  int
+		max     int
+	}{
+		{"1.6", "§1\x0078\x001.6.4\x00§aA Minecraft Server\x003\x0020", "§aA Minecraft Server", "1.6.4", 3, 20},
+		{"1.3", "A Minecraft Server§3§20", "A Minecraft Server", "", 3, 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			port := serve(t, func(conn net.Conn) {
+				req := make([]byte, 3)
+				if _, err := io.ReadFull(conn, req); err != nil || !bytes.Equal(req, []byte{0xFE, 0x01, 0xFA}) {
+					t.Errorf("request starts % x, %v", req, err)
+				}
+				conn.Write(legacyReply(tt.reply))
+			})
+
+			status, err := pingLegacy(context.Background(), "127.0.0.1", port)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !status.Legacy {
+				t.Error("not marked legacy")
+			}
+			if status.MOTD != tt.motd || status.Version.Name != tt.version {
+				t.Errorf("MOTD %q version %q, want %q and %q", status.MOTD, status.Version.Name, tt.motd, tt.version)
+			}
+			if status.Players.Online != tt.online || status.Players.Max != tt.max {
+				t.Errorf("players %d/%d, want %d/%d", status.Players.Online, status.Players.Max, tt.online, tt.max)
+			}
+		})
+	}
+}
+
+func TestPingLegacyBadResponse(t *testing.T) {
+	port := serve(t, func(conn net.Conn) {
+		conn.Write(legacyReply("§1\x0078\x001.6.4"))
+	})
+
+	if _, err := pingLegacy(context.Background(), "127.0.0.1", port); !errors.Is(err, ErrBadResponse) {
+		t.Errorf("got %v, want ErrBadResponse", err)
+	}
+}
@@ -0,0 +1,355 @@
+// query is a client for Minecraft's Query protocol, the GameSpy4-derived UDP protocol servers speak when enable-query is
+// set in server.properties. It reports things RCON can't tell you without parsing free text: the MOTD, game type, map,
+// version, plugins, and the player count and names.
+package query
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/*
+From wiki.vg/Query:
+
+	All packets are big-endian, except the host port in the basic stat response which is little-endian.
+	Requests start with the magic FE FD, then a type byte (9 for handshake, 0 for stat) and a session ID.
+	Responses start with the type byte and the session ID.
+
+	Handshake
+	  Request:  FE FD 09 <session>
+	  Response: 09 <session> <challenge token as a null terminated decimal string>
+	Basic stat
+	  Request:  FE FD 00 <session> <challenge token as int32>
+	  Response: 00 <session> MOTD, gametype, map, numplayers, maxplayers (null terminated strings),
+	            hostport (little-endian short), hostip (null terminated string)
+	Full stat
+	  Request:  FE FD 00 <session> <challenge token as int32> 00 00 00 00
+	  Response: 00 <session> <11 bytes of padding> key/value pairs (null terminated strings, ending with an empty key)
+	            <10 bytes of padding> player names (null terminated strings, ending with an empty name)
+
+	Only the lower 4 bits of each byte of the session ID are used by the server.
+	Challenge tokens are good for 30 seconds.
+*/
+
+const (
+	typeHandshake byte = 9
+	typeStat      byte = 0
+
+	// tokenLifetime is a little under the 30 seconds the server honours a challenge token for.
+	tokenLifetime = 25 * time.Second
+
+	// DefaultTimeout applies when the caller's context has no deadline. UDP packets get lost, and then we'd wait forever.
+	DefaultTimeout = 5 * time.Second
+)
+
+// ErrBadResponse is returned when the server's reply can't be parsed.
+var ErrBadResponse = errors.New("query: malformed response")
+
+// BasicStat is the server summary returned by a basic stat request.
+type BasicStat struct {
+	MOTD       string `json:"motd"`
+	GameType   string `json:"gameType"`
+	Map        string `json:"map"`
+	NumPlayers int    `json:"numPlayers"`
+	MaxPlayers int    `json:"maxPlayers"`
+	HostPort   int    `json:"hostPort"`
+	HostIP     string `json:"hostIp"`
+}
+
+// FullStat is everything a full stat request reports.
+type FullStat struct {
+	BasicStat
+	GameID    string   `json:"gameId"`
+	Version   string   `json:"version"`
+	ServerMod string   `json:"serverMod,omitempty"` // e.g. "CraftBukkit on Bukkit 1.2.5-R4.0". Empty on vanilla.
+	Plugins   []string `json:"plugins"`
+	Players   []string `json:"players"`
+}
+
+// Client asks a single server for its stats. It's safe for concurrent use.
+type Client struct {
+	// Timeout bounds each request when the context passed in has no deadline of its own. Zero means DefaultTimeout.
+	Timeout time.Duration
+
+	m        sync.Mutex
+	conn     net.Conn
+	session  int32
+	token    int32
+	tokenAge time.Time
+}
+
+// NewClient sets up a client for the server's query port. Nothing is sent until a stat is requested.
+func NewClient(addr string, port int) (*Client, error) {
+	conn, err := net.Dial("udp", net.JoinHostPort(addr, strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{
+		conn:    conn,
+		session: rand.Int31() & 0x0F0F0F0F,
+	}, nil
+}
+
+// Close releases the client's socket.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// BasicStat asks the server for its basic stats.
+func (c *Client) BasicStat(ctx context.Context) (*BasicStat, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	r, err := c.stat(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var strs [5]string
+	for i := range strs {
+		if strs[i], err = readString(r); err != nil {
+			return nil, err
+		}
+	}
+
+	var hostPort uint16
+	if err := binary.Read(r, binary.LittleEndian, &hostPort); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBadResponse, err)
+	}
+	hostIP, err := readString(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BasicStat{
+		MOTD:       strs[0],
+		GameType:   strs[1],
+		Map:        strs[2],
+		NumPlayers: atoi(strs[3]),
+		MaxPlayers: atoi(strs[4]),
+		HostPort:   int(hostPort),
+		HostIP:     hostIP,
+	}, nil
+}
+
+// FullStat asks the server for everything, including the plugin list and player names.
+func (c *Client) FullStat(ctx context.Context) (*FullStat, error) {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	r, err := c.stat(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := r.Discard(11); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBadResponse, err)
+	}
+
+	kv := make(map[string]string)
+	for {
+		k, err := readString(r)
+		if err != nil {
+			return nil, err
+		}
+		if k == "" {
+			break
+		}
+		if kv[k], err = readString(r); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := r.Discard(10); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrBadResponse, err)
+	}
+
+	players := []string{}
+	for {
+		p, err := readString(r)
+		if err != nil {
+			return nil, err
+		}
+		if p == "" {
+			break
+		}
+		players = append(players, p)
+	}
+
+	fs := &FullStat{
+		BasicStat: BasicStat{
+			MOTD:       kv["hostname"],
+			GameType:   kv["gametype"],
+			Map:        kv["map"],
+			NumPlayers: atoi(kv["numplayers"]),
+			MaxPlayers: atoi(kv["maxplayers"]),
+			HostPort:   atoi(kv["hostport"]),
+			HostIP:     kv["hostip"],
+		},
+		GameID:  kv["game_id"],
+		Version: kv["version"],
+		Players: players,
+	}
+	fs.ServerMod, fs.Plugins = parsePlugins(kv["plugins"])
+
+	return fs, nil
+}
+
+// parsePlugins splits the plugins value, which looks like "CraftBukkit on Bukkit 1.2.5-R4.0: WorldEdit 5.3; CommandBook 2.1",
+// into the server mod and the list of plugins. Vanilla servers send an empty string.
+func parsePlugins(s string) (string, []string) {
+	plugins := []string{}
+
+	mod, list := s, ""
+	if i := strings.Index(s, ": "); i >= 0 {
+		mod, list = s[:i], s[i+2:]
+	}
+
+	for _, p := range strings.Split(list, "; ") {
+		if p = strings.TrimSpace(p); p != "" {
+			plugins = append(plugins, p)
+		}
+	}
+
+	return strings.TrimSpace(mod), plugins
+}
+
+// stat sends a stat request, handshaking first if needed, and returns a reader positioned just after the response header.
+//
+// The server doesn't answer a request with an expired or unknown token, which is what we'll have if it restarted since
+// the handshake. So if the stat fails the token is thrown away, and it's tried once more with a fresh one.
+func (c *Client) stat(ctx context.Context, full bool) (*bufio.Reader, error) {
+	for retried := false; ; retried = true {
+		if err := c.handshake(ctx); err != nil {
+			return nil, err
+		}
+
+		payload := make([]byte, 4, 8)
+		binary.BigEndian.PutUint32(payload, uint32(c.token))
+		if full {
+			payload = append(payload, 0, 0, 0, 0)
+		}
+
+		resp, err := c.roundTrip(ctx, typeStat, payload)
+		if err == nil {
+			return bufio.NewReader(bytes.NewReader(resp)), nil
+		}
+
+		c.tokenAge = time.Time{}
+		if retried || ctx.Err() != nil {
+			return nil, err
+		}
+	}
+}
+
+// handshake gets a fresh challenge token if the one we have is about to expire.
+func (c *Client) handshake(ctx context.Context) error {
+	if !c.tokenAge.IsZero() && time.Since(c.tokenAge) < tokenLifetime {
+		return nil
+	}
+
+	resp, err := c.roundTrip(ctx, typeHandshake, nil)
+	if err != nil {
+		return err
+	}
+
+	s, err := readString(bufio.NewReader(bytes.NewReader(resp)))
+	if err != nil {
+		return err
+	}
+	token, err := strconv.ParseInt(s, 10, 32)
+	if err != nil {
+		return fmt.Errorf("%w: bad challenge token %q", ErrBadResponse, s)
+	}
+
+	c.token = int32(token)
+	c.tokenAge = time.Now()
+	return nil
+}
+
+// roundTrip sends one request and returns the body of the matching response, after the type and session ID.
+func (c *Client) roundTrip(ctx context.Context, typ byte, payload []byte) ([]byte, error) {
+	stop := c.watchContext(ctx)
+	defer stop()
+
+	req := []byte{0xFE, 0xFD, typ, 0, 0, 0, 0}
+	binary.BigEndian.PutUint32(req[3:], uint32(c.session))
+	req = append(req, payload...)
+
+	if _, err := c.conn.Write(req); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 65536)
+	for {
+		n, err := c.conn.Read(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+
+		// Skip anything that isn't the answer to this request, like a late reply to an earlier one.
+		if n < 5 || buf[0] != typ || int32(binary.BigEndian.Uint32(buf[1:])) != c.session {
+			continue
+		}
+
+		return buf[5:n], nil
+	}
+}
+
+// watchContext applies ctx's deadline to the socket, or the client's Timeout if it hasn't got one, and makes a cancelled
+// ctx interrupt a read that's blocked waiting for a reply. The returned func must be called once the request is done.
+func (c *Client) watchContext(ctx context.Context) func() {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		timeout := c.Timeout
+		if timeout <= 0 {
+			timeout = DefaultTimeout
+		}
+		deadline = time.Now().Add(timeout)
+	}
+	c.conn.SetDeadline(deadline)
+
+	done := make(chan struct{})
+	exited := make(chan struct{})
+	go func() {
+		defer close(exited)
+		select {
+		case <-ctx.Done():
+			// A deadline in the past wakes up anything currently blocked on the socket.
+			c.conn.SetDeadline(time.Unix(1, 0))
+		case <-done:
+		}
+	}()
+
+	return func() {
+		close(done)
+		<-exited
+	}
+}
+
+func readString(r *bufio.Reader) (string, error) {
+	s, err := r.ReadString(0)
+	if err != nil {
+		return "", fmt.Errorf("%w: unterminated string", ErrBadResponse)
+	}
+	return s[:len(s)-1], nil
+}
+
+func atoi(s string) int {
+	i, _ := strconv.Atoi(s)
+	return i
+}
@@ -0,0 +1,235 @@
+package query
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeServer answers query requests the way a vanilla server does, including ignoring stats with a stale token.
+type fakeServer struct {
+	conn net.PacketConn
+
+	m          sync.Mutex
+	token      int32
+	handshakes int
+	silent     bool // Drop everything, as if the port were firewalled
+}
+
+func newFakeServer(t *testing.T) *fakeServer {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	s := &fakeServer{conn: conn, token: 9513307}
+	go s.serve()
+	return s
+}
+
+func (s *fakeServer) client(t *testing.T) *Client {
+	c, err := NewClient("127.0.0.1", s.conn.LocalAddr().(*net.UDPAddr).Port)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Timeout = 200 * time.Millisecond
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func (s *fakeServer) handshakeCount() int {
+	s.m.Lock()
+	defer s.m.Unlock()
+	return s.handshakes
+}
+
+func (s *fakeServer) setSilent(silent bool) {
+	s.m.Lock()
+	s.silent = silent
+	s.m.Unlock()
+}
+
+// rotateToken is what the server does every 30 seconds, and on restart.
+func (s *fakeServer) rotateToken() {
+	s.m.Lock()
+	s.token++
+	s.m.Unlock()
+}
+
+func (s *fakeServer) serve() {
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if n < 7 || buf[0] != 0xFE || buf[1] != 0xFD {
+			continue
+		}
+		typ, session, body := buf[2], buf[3:7], buf[7:n]
+
+		s.m.Lock()
+		resp := append([]byte{typ}, session...)
+		switch {
+		case s.silent:
+			resp = nil
+		case typ == typeHandshake:
+			s.handshakes++
+			resp = append(resp, strconv.Itoa(int(s.token))...)
+			resp = append(resp, 0)
+		case typ == typeStat && len(body) >= 4 && int32(binary.BigEndian.Uint32(body)) == s.token:
+			if len(body) == 8 {
+				resp = append(resp, fullStatBody...)
+			} else {
+				resp = append(resp, basicStatBody...)
+			}
+		default:
+			resp = nil
+		}
+		s.m.Unlock()
+
+		if resp != nil {
+			s.conn.WriteTo(resp, addr)
+		}
+	}
+}
+
+var basicStatBody = []byte("A Minecraft Server\x00SMP\x00world\x002\x0020\x00\xdd\x63127.0.0.1\x00")
+
+var fullStatBody = bytes.Join([][]byte{
+	[]byte("splitnum\x00\x80\x00"),
+	[]byte("hostname\x00A Minecraft Server\x00"),
+	[]byte("gametype\x00SMP\x00"),
+	[]byte("game_id\x00MINECRAFT\x00"),
+	[]byte("version\x001.20.4\x00"),
+	[]byte("plugins\x00CraftBukkit on Bukkit 1.20.4-R0.1: WorldEdit 7.2; LuckPerms 5.4\x00"),
+	[]byte("map\x00world\x00"),
+	[]byte("numplayers\x002\x00"),
+	[]byte("maxplayers\x0020\x00"),
+	[]byte("hostport\x0025565\x00"),
+	[]byte("hostip\x00127.0.0.1\x00"),
+	[]byte("\x00"),
+	[]byte("\x01player_\x00\x00"),
+	[]byte("Notch\x00jeb_\x00\x00"),
+}, nil)
+
+func TestBasicStat(t *testing.T) {
+	c := newFakeServer(t).client(t)
+
+	got, err := c.BasicStat(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &BasicStat{MOTD: "A Minecraft Server", GameType: "SMP", Map: "world", NumPlayers: 2, MaxPlayers: 20, HostPort: 25565, HostIP: "127.0.0.1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestFullStat(t *testing.T) {
+	c := newFakeServer(t).client(t)
+
+	got, err := c.FullStat(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := &FullStat{
+		BasicStat: BasicStat{MOTD: "A Minecraft Server", GameType: "SMP", Map: "world", NumPlayers: 2, MaxPlayers: 20, HostPort: 25565, HostIP: "127.0.0.1"},
+		GameID:    "MINECRAFT",
+		Version:   "1.20.4",
+		ServerMod: "CraftBukkit on Bukkit 1.20.4-R0.1",
+		Plugins:   []string{"WorldEdit 7.2", "LuckPerms 5.4"},
+		Players:   []string{"Notch", "jeb_"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestHandshakeReusesToken(t *testing.T) {
+	s := newFakeServer(t)
+	c := s.client(t)
+
+	for i := 0; i < 3; i++ {
+		if _, err := c.BasicStat(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := s.handshakeCount(); n != 1 {
+		t.Errorf("%d handshakes for 3 stats with a fresh token, want 1", n)
+	}
+
+	// Once it's near its 30 seconds, the token is replaced before it's used.
+	c.tokenAge = time.Now().Add(-tokenLifetime)
+	if _, err := c.BasicStat(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if n := s.handshakeCount(); n != 2 {
+		t.Errorf("%d handshakes after the token aged, want 2", n)
+	}
+}
+
+func TestExpiredTokenRetries(t *testing.T) {
+	s := newFakeServer(t)
+	c := s.client(t)
+
+	if _, err := c.BasicStat(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	// The server has forgotten our token, e.g. because it restarted, so the stat goes unanswered until we handshake again.
+	s.rotateToken()
+	if _, err := c.FullStat(context.Background()); err != nil {
+		t.Fatalf("stat with an expired token wasn't retried: %v", err)
+	}
+	if n := s.handshakeCount(); n != 2 {
+		t.Errorf("%d handshakes, want 2", n)
+	}
+}
+
+func TestFailedStatForgetsToken(t *testing.T) {
+	s := newFakeServer(t)
+	c := s.client(t)
+
+	if _, err := c.BasicStat(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	s.setSilent(true)
+
+	var ne net.Error
+	if _, err := c.BasicStat(context.Background()); !errors.As(err, &ne) || !ne.Timeout() {
+		t.Fatalf("got %v, want a timeout", err)
+	}
+	if !c.tokenAge.IsZero() {
+		t.Error("token kept after a failed stat")
+	}
+}
+
+func TestCancelWithoutDeadline(t *testing.T) {
+	s := newFakeServer(t)
+	s.setSilent(true)
+	c := s.client(t)
+	c.Timeout = time.Minute
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	if _, err := c.BasicStat(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want context.Canceled", err)
+	}
+	if waited := time.Since(start); waited > 5*time.Second {
+		t.Errorf("cancelling took %v", waited)
+	}
+}
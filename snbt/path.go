@@ -0,0 +1,135 @@
+package snbt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Get looks up the tag at path inside t, using the same NBT path syntax as commands:
+//
+//	Inventory[0].tag.display.Name
+//	Pos[-1]
+//	"key with spaces".value
+//
+// Compound keys are separated by dots and may be quoted, and [n] indexes a list or array, counting from the end if n is
+// negative. An empty path returns t itself. Looking up something that isn't there is an error.
+func Get(t Tag, path string) (Tag, error) {
+	steps, err := parsePath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, st := range steps {
+		if st.key != nil {
+			c, ok := t.(Compound)
+			if !ok {
+				return nil, fmt.Errorf("snbt: %s is a %s, not a compound", st, t.Kind())
+			}
+			if t, ok = c[*st.key]; !ok {
+				return nil, fmt.Errorf("snbt: no %s", st)
+			}
+			continue
+		}
+
+		items, ok := indexable(t)
+		if !ok {
+			return nil, fmt.Errorf("snbt: can't index a %s with %s", t.Kind(), st)
+		}
+		i := st.index
+		if i < 0 {
+			i += len(items)
+		}
+		if i < 0 || i >= len(items) {
+			return nil, fmt.Errorf("snbt: %s out of range, length %d", st, len(items))
+		}
+		t = items[i]
+	}
+
+	return t, nil
+}
+
+// pathStep is a single compound key or index in a path.
+type pathStep struct {
+	key   *string
+	index int
+}
+
+func (st pathStep) String() string {
+	if st.key != nil {
+		return fmt.Sprintf("key %q", *st.key)
+	}
+	return fmt.Sprintf("index [%d]", st.index)
+}
+
+// indexable returns the elements of a list or typed array as tags.
+func indexable(t Tag) ([]Tag, bool) {
+	switch v := t.(type) {
+	case List:
+		return v, true
+	case ByteArray:
+		items := make([]Tag, len(v))
+		for i, b := range v {
+			items[i] = Byte(b)
+		}
+		return items, true
+	case IntArray:
+		items := make([]Tag, len(v))
+		for i, n := range v {
+			items[i] = Int(n)
+		}
+		return items, true
+	case LongArray:
+		items := make([]Tag, len(v))
+		for i, n := range v {
+			items[i] = Long(n)
+		}
+		return items, true
+	}
+	return nil, false
+}
+
+func parsePath(path string) ([]pathStep, error) {
+	p := &parser{s: path}
+	var steps []pathStep
+
+	for p.pos < len(p.s) {
+		switch c := p.peek(); {
+		case c == '[':
+			end := strings.IndexByte(p.s[p.pos:], ']')
+			if end < 0 {
+				return nil, p.errorf("unterminated index in path")
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(p.s[p.pos+1 : p.pos+end]))
+			if err != nil {
+				return nil, p.errorf("bad index in path")
+			}
+			steps = append(steps, pathStep{index: n})
+			p.pos += end + 1
+
+		case c == '.' && len(steps) > 0:
+			p.pos++
+
+		case c == '"' || c == '\'':
+			key, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			steps = append(steps, pathStep{key: &key})
+
+		default:
+			// Unlike in SNBT values, dots separate path steps rather than being part of the key.
+			start := p.pos
+			for p.pos < len(p.s) && strings.IndexByte(".[", p.s[p.pos]) < 0 {
+				p.pos++
+			}
+			key := p.s[start:p.pos]
+			if key == "" {
+				return nil, p.errorf("unexpected %q in path", c)
+			}
+			steps = append(steps, pathStep{key: &key})
+		}
+	}
+
+	return steps, nil
+}
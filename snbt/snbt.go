@@ -0,0 +1,346 @@
+// snbt parses stringified NBT, the text form of Minecraft's NBT data that commands like "data get" print, into a typed
+// tree. Trees can be queried with NBT paths, and converted to plain Go values or JSON.
+package snbt
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+/*
+From the Minecraft wiki, NBT format:
+
+	Byte     1b, or true/false        Compound   {key: value, "quoted key": value}
+	Short    1s                       List       [value, value]  (all the same type)
+	Int      1                        Byte array [B; 1b, 2b]
+	Long     1L                       Int array  [I; 1, 2]
+	Float    1.0f                     Long array [L; 1L, 2L]
+	Double   1.0d, or 1.0
+	String   "quoted" or 'quoted', or unquoted if it's only made of 0-9 A-Z a-z _ - . +
+
+Number suffixes may be upper or lower case. Integers can't have leading zeros, and a double without its suffix needs a
+decimal point, so the game reads unquoted tokens like 007 or 1e5 as strings.
+
+Like the game, we allow a trailing comma in compounds, lists and arrays.
+*/
+
+var (
+	intRe        = regexp.MustCompile(`^[-+]?(?:0|[1-9][0-9]*)([bBsSlL]?)$`)
+	floatRe      = regexp.MustCompile(`^[-+]?(?:[0-9]+\.?|[0-9]*\.[0-9]+)(?:[eE][-+]?[0-9]+)?([fFdD])$`)
+	bareDoubleRe = regexp.MustCompile(`^[-+]?(?:[0-9]+\.|[0-9]*\.[0-9]+)(?:[eE][-+]?[0-9]+)?$`)
+)
+
+// Parse parses a single SNBT value.
+func Parse(s string) (Tag, error) {
+	p := &parser{s: s}
+
+	v, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipSpace()
+	if p.pos != len(p.s) {
+		return nil, p.errorf("unexpected %q after value", p.s[p.pos:])
+	}
+	return v, nil
+}
+
+type parser struct {
+	s   string
+	pos int
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("snbt: at offset %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.s) && strings.IndexByte(" \t\r\n", p.s[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+func (p *parser) peek() byte {
+	if p.pos >= len(p.s) {
+		return 0
+	}
+	return p.s[p.pos]
+}
+
+func (p *parser) expect(c byte) error {
+	p.skipSpace()
+	if p.peek() != c {
+		return p.errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *parser) value() (Tag, error) {
+	p.skipSpace()
+
+	switch c := p.peek(); {
+	case c == 0:
+		return nil, p.errorf("unexpected end of input")
+	case c == '{':
+		return p.compound()
+	case c == '[':
+		return p.list()
+	case c == '"' || c == '\'':
+		str, err := p.quoted()
+		return String(str), err
+	default:
+		tok := p.unquoted()
+		if tok == "" {
+			return nil, p.errorf("unexpected %q", c)
+		}
+		return scalar(tok), nil
+	}
+}
+
+func (p *parser) compound() (Tag, error) {
+	p.pos++ // {
+	m := make(Compound)
+
+	p.skipSpace()
+	if p.peek() == '}' {
+		p.pos++
+		return m, nil
+	}
+
+	for {
+		p.skipSpace()
+
+		var key string
+		if c := p.peek(); c == '"' || c == '\'' {
+			k, err := p.quoted()
+			if err != nil {
+				return nil, err
+			}
+			key = k
+		} else if key = p.unquoted(); key == "" {
+			return nil, p.errorf("expected compound key")
+		}
+
+		if err := p.expect(':'); err != nil {
+			return nil, err
+		}
+
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		m[key] = v
+
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+			if p.skipSpace(); p.peek() == '}' {
+				p.pos++
+				return m, nil
+			}
+		case '}':
+			p.pos++
+			return m, nil
+		default:
+			return nil, p.errorf("expected ',' or '}' in compound")
+		}
+	}
+}
+
+func (p *parser) list() (Tag, error) {
+	p.pos++ // [
+
+	// Typed arrays start with their type letter and a semicolon. Nothing else in a list can be followed by a semicolon,
+	// so it's safe to look past the whitespace for one.
+	start := p.pos
+	p.skipSpace()
+	if t := p.peek(); t == 'B' || t == 'I' || t == 'L' {
+		p.pos++
+		if p.skipSpace(); p.peek() == ';' {
+			p.pos++
+			return p.array(t)
+		}
+	}
+	p.pos = start
+
+	items := List{}
+
+	p.skipSpace()
+	if p.peek() == ']' {
+		p.pos++
+		return items, nil
+	}
+
+	for {
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+
+		p.skipSpace()
+		switch p.peek() {
+		case ',':
+			p.pos++
+			if p.skipSpace(); p.peek() == ']' {
+				p.pos++
+				return items, nil
+			}
+		case ']':
+			p.pos++
+			return items, nil
+		default:
+			return nil, p.errorf("expected ',' or ']' in list")
+		}
+	}
+}
+
+func (p *parser) array(t byte) (Tag, error) {
+	bytes := ByteArray{}
+	ints := IntArray{}
+	longs := LongArray{}
+
+	p.skipSpace()
+	for p.peek() != ']' {
+		p.skipSpace()
+		tok := p.unquoted()
+		m := intRe.FindStringSubmatch(tok)
+		if m == nil {
+			return nil, p.errorf("bad %c array element %q", t, tok)
+		}
+		digits := strings.TrimRight(tok, "bBsSlL")
+
+		var err error
+		switch t {
+		case 'B':
+			var n int64
+			n, err = strconv.ParseInt(digits, 10, 8)
+			bytes = append(bytes, int8(n))
+		case 'I':
+			var n int64
+			n, err = strconv.ParseInt(digits, 10, 32)
+			ints = append(ints, int32(n))
+		case 'L':
+			var n int64
+			n, err = strconv.ParseInt(digits, 10, 64)
+			longs = append(longs, n)
+		}
+		if err != nil {
+			return nil, p.errorf("bad %c array element %q", t, tok)
+		}
+
+		p.skipSpace()
+		if p.peek() == ',' {
+			p.pos++
+			p.skipSpace()
+		} else if p.peek() != ']' {
+			return nil, p.errorf("expected ',' or ']' in array")
+		}
+	}
+	p.pos++ // ]
+
+	switch t {
+	case 'B':
+		return bytes, nil
+	case 'I':
+		return ints, nil
+	}
+	return longs, nil
+}
+
+func (p *parser) quoted() (string, error) {
+	q := p.s[p.pos]
+	p.pos++
+
+	var b strings.Builder
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		p.pos++
+
+		switch {
+		case c == '\\':
+			if p.pos >= len(p.s) {
+				return "", p.errorf("unterminated string")
+			}
+			b.WriteByte(p.s[p.pos])
+			p.pos++
+		case c == q:
+			return b.String(), nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	return "", p.errorf("unterminated string")
+}
+
+func (p *parser) unquoted() string {
+	start := p.pos
+	for p.pos < len(p.s) {
+		c := p.s[p.pos]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || strings.IndexByte("_-.+", c) >= 0) {
+			break
+		}
+		p.pos++
+	}
+	return p.s[start:p.pos]
+}
+
+// scalar works out what an unquoted token is: a number if it looks like one, a boolean byte, or else a string.
+func scalar(tok string) Tag {
+	if m := intRe.FindStringSubmatch(tok); m != nil {
+		digits := tok[:len(tok)-len(m[1])]
+		var err error
+		var n int64
+
+		switch m[1] {
+		case "b", "B":
+			if n, err = strconv.ParseInt(digits, 10, 8); err == nil {
+				return Byte(n)
+			}
+		case "s", "S":
+			if n, err = strconv.ParseInt(digits, 10, 16); err == nil {
+				return Short(n)
+			}
+		case "l", "L":
+			if n, err = strconv.ParseInt(digits, 10, 64); err == nil {
+				return Long(n)
+			}
+		default:
+			if n, err = strconv.ParseInt(digits, 10, 32); err == nil {
+				return Int(n)
+			}
+		}
+	}
+
+	if m := floatRe.FindStringSubmatch(tok); m != nil {
+		digits := tok[:len(tok)-1]
+		if m[1] == "f" || m[1] == "F" {
+			if f, err := strconv.ParseFloat(digits, 32); err == nil {
+				return Float(f)
+			}
+		} else if f, err := strconv.ParseFloat(digits, 64); err == nil {
+			return Double(f)
+		}
+	}
+
+	if bareDoubleRe.MatchString(tok) {
+		if f, err := strconv.ParseFloat(tok, 64); err == nil {
+			return Double(f)
+		}
+	}
+
+	switch tok {
+	case "true":
+		return Byte(1)
+	case "false":
+		return Byte(0)
+	}
+
+	return String(tok)
+}
@@ -0,0 +1,204 @@
+package snbt
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Tag
+	}{
+		// Numbers, with each suffix in both cases.
+		{"1b", Byte(1)},
+		{"-128B", Byte(-128)},
+		{"true", Byte(1)},
+		{"false", Byte(0)},
+		{"12s", Short(12)},
+		{"-32768S", Short(-32768)},
+		{"42", Int(42)},
+		{"+42", Int(42)},
+		{"-2147483648", Int(-2147483648)},
+		{"0", Int(0)},
+		{"9000000000L", Long(9000000000)},
+		{"5l", Long(5)},
+		{"1.5f", Float(1.5)},
+		{"2F", Float(2)},
+		{"1.5d", Double(1.5)},
+		{"3D", Double(3)},
+		{"1.5", Double(1.5)},
+		{".5", Double(.5)},
+		{"1.", Double(1)},
+		{"-1.5e3", Double(-1500)},
+		{"1e3d", Double(1000)},
+		{"007d", Double(7)},
+
+		// Tokens that look numeric but that the game reads as strings.
+		{"007", String("007")},
+		{"1e3", String("1e3")},
+		{"300b", String("300b")},
+		{"2147483648", String("2147483648")},
+		{"1.5.5", String("1.5.5")},
+
+		// Strings.
+		{"stone", String("stone")},
+		{"a-b_c.d+e", String("a-b_c.d+e")},
+		{`"hello world"`, String("hello world")},
+		{`'hello world'`, String("hello world")},
+		{`"say \"hi\""`, String(`say "hi"`)},
+		{`'it\'s'`, String("it's")},
+		{`"it's"`, String("it's")},
+		{`'say "hi"'`, String(`say "hi"`)},
+		{`"back\\slash"`, String(`back\slash`)},
+		{`""`, String("")},
+		{`"§6gold"`, String("§6gold")},
+
+		// Lists and arrays.
+		{"[]", List{}},
+		{"[ ]", List{}},
+		{"[1, 2, 3]", List{Int(1), Int(2), Int(3)}},
+		{"[1,2,3,]", List{Int(1), Int(2), Int(3)}},
+		{"[ 1 , 2 , ]", List{Int(1), Int(2)}},
+		{"[a, 'b c']", List{String("a"), String("b c")}},
+		{"[I, B]", List{String("I"), String("B")}},
+		{"[B; 1b, -2b]", ByteArray{1, -2}},
+		{"[I; 1, 2]", IntArray{1, 2}},
+		{"[I;1,2,]", IntArray{1, 2}},
+		{"[ I; 1]", IntArray{1}},
+		{"[I ;1]", IntArray{1}},
+		{"[I;]", IntArray{}},
+		{"[L; 1L, 9000000000L]", LongArray{1, 9000000000}},
+
+		// Compounds.
+		{"{}", Compound{}},
+		{"{ }", Compound{}},
+		{"{a: 1}", Compound{"a": Int(1)}},
+		{"{a:1,b:2,}", Compound{"a": Int(1), "b": Int(2)}},
+		{`{"quoted key": 1b, 'single': "x"}`, Compound{"quoted key": Byte(1), "single": String("x")}},
+		{
+			`{Pos: [1.5d, 64.0d, -3.25d], Inventory: [{Slot: 0b, id: "minecraft:stone", Count: 64b}], UUID: [I; 1, 2, 3, 4]}`,
+			Compound{
+				"Pos":       List{Double(1.5), Double(64), Double(-3.25)},
+				"Inventory": List{Compound{"Slot": Byte(0), "id": String("minecraft:stone"), "Count": Byte(64)}},
+				"UUID":      IntArray{1, 2, 3, 4},
+			},
+		},
+		{"[[1], [[2]], []]", List{List{Int(1)}, List{List{Int(2)}}, List{}}},
+		{"  {a: {b: {c: 'deep'}}}  ", Compound{"a": Compound{"b": Compound{"c": String("deep")}}}},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tt.in, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Parse(%q) = %#v, want %#v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		in  string
+		err string
+	}{
+		{"", "at offset 0: unexpected end of input"},
+		{"minecraft:stone", `at offset 9: unexpected ":stone" after value`},
+		{"{a 1}", `at offset 3: expected ':'`},
+		{"{a: 1 b: 2}", `at offset 6: expected ',' or '}' in compound`},
+		{"{a: 1,,}", "at offset 6: expected compound key"},
+		{"{,}", "at offset 1: expected compound key"},
+		{"{a: 1", `at offset 5: expected ',' or '}' in compound`},
+		{"[1 2]", `at offset 3: expected ',' or ']' in list`},
+		{"[1,,]", `at offset 3: unexpected ','`},
+		{"[,]", `at offset 1: unexpected ','`},
+		{"[1", `at offset 2: expected ',' or ']' in list`},
+		{"[I; 1, x]", `at offset 8: bad I array element "x"`},
+		{"[B; 300b]", `at offset 8: bad B array element "300b"`},
+		{"[I; 1 2]", `at offset 6: expected ',' or ']' in array`},
+		{"[I; 1,,]", `at offset 6: bad I array element ""`},
+		{`"unterminated`, "at offset 13: unterminated string"},
+		{`"trailing \`, "at offset 11: unterminated string"},
+		{"1 2", `at offset 2: unexpected "2" after value`},
+		{"{a: @}", `at offset 4: unexpected '@'`},
+	}
+
+	for _, tt := range tests {
+		_, err := Parse(tt.in)
+		if err == nil {
+			t.Errorf("Parse(%q) succeeded, want error %q", tt.in, tt.err)
+			continue
+		}
+		if !strings.HasSuffix(err.Error(), tt.err) {
+			t.Errorf("Parse(%q) error %q, want %q", tt.in, err, tt.err)
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	data, err := Parse(`{Pos: [1.5d, 64.0d, -3.25d], Inventory: [{Slot: 0b, tag: {display: {Name: '"Sword"'}}}], UUID: [I; 1, 2], "odd key": {"a.b": 1}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want Tag
+	}{
+		{"Pos[0]", Double(1.5)},
+		{"Pos[-1]", Double(-3.25)},
+		{"Inventory[0].Slot", Byte(0)},
+		{"Inventory[0].tag.display.Name", String(`"Sword"`)},
+		{"UUID[1]", Int(2)},
+		{`"odd key"."a.b"`, Int(1)},
+		{`'odd key'`, Compound{"a.b": Int(1)}},
+		{"", data},
+	}
+	for _, tt := range tests {
+		got, err := Get(data, tt.path)
+		if err != nil {
+			t.Errorf("Get(%q): %v", tt.path, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Get(%q) = %#v, want %#v", tt.path, got, tt.want)
+		}
+	}
+
+	errors := []struct {
+		path string
+		err  string
+	}{
+		{"Missing", `snbt: no key "Missing"`},
+		{"Pos[3]", "snbt: index [3] out of range, length 3"},
+		{"Pos[-4]", "snbt: index [-4] out of range, length 3"},
+		{"Pos.x", `snbt: key "x" is a list, not a compound`},
+		{"Inventory[0].Slot[0]", "snbt: can't index a byte with index [0]"},
+		{"Pos[x]", "snbt: at offset 3: bad index in path"},
+		{"Pos[0", "snbt: at offset 3: unterminated index in path"},
+		{`"unterminated`, "snbt: at offset 13: unterminated string"},
+	}
+	for _, tt := range errors {
+		if _, err := Get(data, tt.path); err == nil || err.Error() != tt.err {
+			t.Errorf("Get(%q) error %v, want %q", tt.path, err, tt.err)
+		}
+	}
+}
+
+func TestToJSON(t *testing.T) {
+	data, err := Parse(`{b: [B; 1b], i: [1s, 2s], s: "x", f: 0.5f, n: {}}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	js, err := ToJSON(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"b":[1],"f":0.5,"i":[1,2],"n":{},"s":"x"}`; string(js) != want {
+		t.Errorf("got %s, want %s", js, want)
+	}
+}
@@ -0,0 +1,158 @@
+package snbt
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// Kind is the NBT type of a Tag.
+type Kind int
+
+const (
+	KindByte Kind = iota + 1
+	KindShort
+	KindInt
+	KindLong
+	KindFloat
+	KindDouble
+	KindString
+	KindList
+	KindCompound
+	KindByteArray
+	KindIntArray
+	KindLongArray
+)
+
+var kindNames = map[Kind]string{
+	KindByte:      "byte",
+	KindShort:     "short",
+	KindInt:       "int",
+	KindLong:      "long",
+	KindFloat:     "float",
+	KindDouble:    "double",
+	KindString:    "string",
+	KindList:      "list",
+	KindCompound:  "compound",
+	KindByteArray: "byte array",
+	KindIntArray:  "int array",
+	KindLongArray: "long array",
+}
+
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// Tag is a node in a parsed SNBT tree. It's always one of the types below, so a type switch gets at the value.
+type Tag interface {
+	Kind() Kind
+}
+
+type (
+	Byte      int8
+	Short     int16
+	Int       int32
+	Long      int64
+	Float     float32
+	Double    float64
+	String    string
+	List      []Tag
+	Compound  map[string]Tag
+	ByteArray []int8
+	IntArray  []int32
+	LongArray []int64
+)
+
+func (Byte) Kind() Kind      { return KindByte }
+func (Short) Kind() Kind     { return KindShort }
+func (Int) Kind() Kind       { return KindInt }
+func (Long) Kind() Kind      { return KindLong }
+func (Float) Kind() Kind     { return KindFloat }
+func (Double) Kind() Kind    { return KindDouble }
+func (String) Kind() Kind    { return KindString }
+func (List) Kind() Kind      { return KindList }
+func (Compound) Kind() Kind  { return KindCompound }
+func (ByteArray) Kind() Kind { return KindByteArray }
+func (IntArray) Kind() Kind  { return KindIntArray }
+func (LongArray) Kind() Kind { return KindLongArray }
+
+// Value converts t to plain Go values: int8, int16, int32, int64, float32, float64 and string for the scalars,
+// []interface{} for lists, map[string]interface{} for compounds, and []int8, []int32 or []int64 for typed arrays.
+func Value(t Tag) interface{} {
+	switch v := t.(type) {
+	case Byte:
+		return int8(v)
+	case Short:
+		return int16(v)
+	case Int:
+		return int32(v)
+	case Long:
+		return int64(v)
+	case Float:
+		return float32(v)
+	case Double:
+		return float64(v)
+	case String:
+		return string(v)
+	case List:
+		items := make([]interface{}, len(v))
+		for i, item := range v {
+			items[i] = Value(item)
+		}
+		return items
+	case Compound:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[k] = Value(item)
+		}
+		return m
+	case ByteArray:
+		return []int8(v)
+	case IntArray:
+		return []int32(v)
+	case LongArray:
+		return []int64(v)
+	}
+	return nil
+}
+
+// ToJSON converts t to JSON. Numbers lose their NBT type on the way, and compound keys come out sorted.
+func ToJSON(t Tag) ([]byte, error) {
+	return json.Marshal(Value(t))
+}
+
+// Float64 returns the value of any numeric tag as a float64, and whether t was numeric.
+func Float64(t Tag) (float64, bool) {
+	switch v := t.(type) {
+	case Byte:
+		return float64(v), true
+	case Short:
+		return float64(v), true
+	case Int:
+		return float64(v), true
+	case Long:
+		return float64(v), true
+	case Float:
+		return float64(v), true
+	case Double:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// Int64 returns the value of any integer tag as an int64, and whether t was an integer.
+func Int64(t Tag) (int64, bool) {
+	switch v := t.(type) {
+	case Byte:
+		return int64(v), true
+	case Short:
+		return int64(v), true
+	case Int:
+		return int64(v), true
+	case Long:
+		return int64(v), true
+	}
+	return 0, false
+}